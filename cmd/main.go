@@ -6,24 +6,43 @@ import (
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 
+	"github.com/souvik150/file-sharing-app/internal/auth"
 	"github.com/souvik150/file-sharing-app/internal/cache"
 	"github.com/souvik150/file-sharing-app/internal/config"
 	"github.com/souvik150/file-sharing-app/internal/cron"
 	"github.com/souvik150/file-sharing-app/internal/database"
+	"github.com/souvik150/file-sharing-app/internal/files"
 	"github.com/souvik150/file-sharing-app/internal/routes"
 	"github.com/souvik150/file-sharing-app/internal/socket"
+	"github.com/souvik150/file-sharing-app/pkg/mailer"
 	appUtils "github.com/souvik150/file-sharing-app/pkg/utils"
 )
 
 func main() {
 	config.LoadConfig()
+	auth.LoadSigningKeys()
+	auth.LoadBreachedPasswords()
+	auth.SetupOIDC()
 	database.Connect()
 	cache.Connect()
+	mailer.Setup()
 
 	db := database.GetDB()
 	database.Migrate(db)
+	files.ResetReservations()
+	auth.BootstrapAdmins()
 
 	cron.CleanUpExpiredLinks()
+	cron.PurgeDeletedAccounts()
+	cron.CleanUpExpiredExports()
+	cron.PurgeExpiredTrash()
+	cron.EnforceVersionRetention()
+	cron.CollectBlobs()
+	cron.ScrubStoredFiles()
+	cron.ReconcileStorageUsage()
+	cron.GenerateThumbnails()
+	cron.IndexPendingFiles()
+	socket.ListenForSessionRevocations()
 
 	router := gin.Default()
 	router.Use(cors.Default())
@@ -0,0 +1,203 @@
+// Command mock-idp is a minimal OpenID Connect provider for exercising single
+// sign-on locally. It approves every authorization request without a login
+// page, signing ID tokens for the email given in the "login_hint" query
+// parameter or MOCK_IDP_EMAIL. Never expose it outside a development machine.
+//
+//	MOCK_IDP_ISSUER=http://localhost:9000 go run ./cmd/mock-idp
+//
+// Point the API at it with OIDC_ISSUER_URL=http://localhost:9000 and
+// OIDC_CLIENT_ID=file-sharing-app, then open /auth/oidc/login in a browser.
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const keyID = "mock-idp"
+
+type authorization struct {
+	ClientID      string
+	RedirectURI   string
+	Nonce         string
+	CodeChallenge string
+	Email         string
+	ExpiresAt     time.Time
+}
+
+var (
+	issuer        = getEnv("MOCK_IDP_ISSUER", "http://localhost:9000")
+	defaultEmail  = getEnv("MOCK_IDP_EMAIL", "employee@example.com")
+	emailVerified = getEnv("MOCK_IDP_EMAIL_VERIFIED", "true") == "true"
+
+	signingKey *rsa.PrivateKey
+
+	codes   = map[string]authorization{}
+	codesMu sync.Mutex
+)
+
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func main() {
+	var err error
+	signingKey, err = rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		log.Fatalf("Failed to generate signing key: %v", err)
+	}
+
+	http.HandleFunc("/.well-known/openid-configuration", discoveryHandler)
+	http.HandleFunc("/jwks", jwksHandler)
+	http.HandleFunc("/authorize", authorizeHandler)
+	http.HandleFunc("/token", tokenHandler)
+
+	issuerURL, err := url.Parse(issuer)
+	if err != nil {
+		log.Fatalf("Invalid MOCK_IDP_ISSUER: %v", err)
+	}
+	addr := ":" + issuerURL.Port()
+	if issuerURL.Port() == "" {
+		addr = ":80"
+	}
+
+	log.Printf("🪪 Mock OIDC provider listening on %s (issuer %s)", addr, issuer)
+	log.Fatal(http.ListenAndServe(addr, nil))
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func discoveryHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                issuer,
+		"authorization_endpoint":                issuer + "/authorize",
+		"token_endpoint":                        issuer + "/token",
+		"jwks_uri":                              issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+		"scopes_supported":                      []string{"openid", "email", "profile"},
+	})
+}
+
+func jwksHandler(w http.ResponseWriter, r *http.Request) {
+	pub := signingKey.PublicKey
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": keyID,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
+
+func authorizeHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	redirectURI, err := url.Parse(query.Get("redirect_uri"))
+	if err != nil || query.Get("redirect_uri") == "" {
+		http.Error(w, "redirect_uri is required", http.StatusBadRequest)
+		return
+	}
+	if query.Get("response_type") != "code" || query.Get("code_challenge_method") != "S256" {
+		http.Error(w, "only response_type=code with S256 PKCE is supported", http.StatusBadRequest)
+		return
+	}
+
+	email := query.Get("login_hint")
+	if email == "" {
+		email = defaultEmail
+	}
+
+	code := uuid.New().String()
+	codesMu.Lock()
+	codes[code] = authorization{
+		ClientID:      query.Get("client_id"),
+		RedirectURI:   redirectURI.String(),
+		Nonce:         query.Get("nonce"),
+		CodeChallenge: query.Get("code_challenge"),
+		Email:         email,
+		ExpiresAt:     time.Now().Add(time.Minute),
+	}
+	codesMu.Unlock()
+
+	callback := redirectURI.Query()
+	callback.Set("code", code)
+	callback.Set("state", query.Get("state"))
+	redirectURI.RawQuery = callback.Encode()
+
+	log.Printf("Approved login for %s", email)
+	http.Redirect(w, r, redirectURI.String(), http.StatusFound)
+}
+
+func tokenHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+		return
+	}
+
+	codesMu.Lock()
+	auth, ok := codes[r.PostForm.Get("code")]
+	delete(codes, r.PostForm.Get("code"))
+	codesMu.Unlock()
+
+	if !ok || time.Now().After(auth.ExpiresAt) || r.PostForm.Get("redirect_uri") != auth.RedirectURI {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	verifierHash := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(verifierHash[:]) != auth.CodeChallenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "PKCE verification failed"})
+		return
+	}
+
+	now := time.Now()
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":            issuer,
+		"sub":            "mock|" + auth.Email,
+		"aud":            auth.ClientID,
+		"email":          auth.Email,
+		"email_verified": emailVerified,
+		"nonce":          auth.Nonce,
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+	})
+	idToken.Header["kid"] = keyID
+
+	signed, err := idToken.SignedString(signingKey)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": uuid.New().String(),
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     signed,
+	})
+}
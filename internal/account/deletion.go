@@ -0,0 +1,225 @@
+package account
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/souvik150/file-sharing-app/internal/audit"
+	"github.com/souvik150/file-sharing-app/internal/auth"
+	appConfig "github.com/souvik150/file-sharing-app/internal/config"
+	"github.com/souvik150/file-sharing-app/internal/database"
+	"github.com/souvik150/file-sharing-app/internal/export"
+	"github.com/souvik150/file-sharing-app/internal/files"
+	"github.com/souvik150/file-sharing-app/internal/models"
+	"github.com/souvik150/file-sharing-app/pkg/mailer"
+)
+
+var ErrNoDeletionScheduled = errors.New("no account deletion is scheduled")
+
+// RequestDeletion schedules the account for deletion once
+// ACCOUNT_DELETION_GRACE_PERIOD has passed. The account keeps working until
+// then so the user can change their mind. Repeated requests keep the
+// original schedule.
+func RequestDeletion(user *models.User, ip string) (time.Time, error) {
+	if user.DeletionScheduledAt != nil {
+		return *user.DeletionScheduledAt, nil
+	}
+
+	now := time.Now()
+	scheduledAt := now.Add(appConfig.AppConfig.AccountDeletionGracePeriod)
+	err := database.GetDB().Model(user).Updates(map[string]interface{}{
+		"deletion_requested_at": now,
+		"deletion_scheduled_at": scheduledAt,
+	}).Error
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to schedule account deletion: %v", err)
+	}
+
+	audit.Record(audit.Entry{
+		ActorID:    &user.ID,
+		Action:     audit.ActionAccountDeletionRequested,
+		TargetType: "user",
+		TargetID:   user.ID.String(),
+		IPAddress:  ip,
+		Details:    map[string]interface{}{"scheduled_at": scheduledAt},
+	})
+
+	err = mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Your account is scheduled for deletion",
+		Body: fmt.Sprintf("Your account and all of its files will be permanently deleted on %s.\n\nIf you did not ask for this, log in and cancel the deletion before then, and change your password.\n",
+			scheduledAt.Format(time.RFC1123)),
+	})
+	if err != nil {
+		log.Printf("Error sending deletion notice to %s: %v", user.Email, err)
+	}
+
+	return scheduledAt, nil
+}
+
+func CancelDeletion(user *models.User, ip string) error {
+	if user.DeletionScheduledAt == nil {
+		return ErrNoDeletionScheduled
+	}
+
+	err := database.GetDB().Model(user).Updates(map[string]interface{}{
+		"deletion_requested_at": nil,
+		"deletion_scheduled_at": nil,
+	}).Error
+	if err != nil {
+		return fmt.Errorf("failed to cancel account deletion: %v", err)
+	}
+
+	audit.Record(audit.Entry{
+		ActorID:    &user.ID,
+		Action:     audit.ActionAccountDeletionCancelled,
+		TargetType: "user",
+		TargetID:   user.ID.String(),
+		IPAddress:  ip,
+	})
+
+	return nil
+}
+
+// PurgeDueAccounts deletes every account whose grace period is over. An
+// account that fails part way is left in place and retried on the next run;
+// whatever was already removed stays removed.
+func PurgeDueAccounts() {
+	var users []models.User
+	err := database.GetDB().
+		Where("deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= ?", time.Now()).
+		Find(&users).Error
+	if err != nil {
+		log.Printf("❌ Error finding accounts due for deletion: %v", err)
+		return
+	}
+
+	for i := range users {
+		if _, err := Purge(&users[i]); err != nil {
+			log.Printf("❌ Error purging account %s: %v", users[i].ID, err)
+		}
+	}
+}
+
+// Purge removes everything belonging to the user, then the user itself, and
+// returns the receipt describing what was deleted. The receipt is also
+// emailed to the address the account had.
+func Purge(user *models.User) (*models.DeletionReceipt, error) {
+	db := database.GetDB()
+
+	sessionsRevoked, err := auth.RevokeUserSessions(user.ID, uuid.Nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var userFiles []models.File
+	if err := db.Unscoped().Where("owner_id = ?", user.ID).Find(&userFiles).Error; err != nil {
+		return nil, fmt.Errorf("failed to load files: %v", err)
+	}
+
+	var sharedLinks int64
+	err = db.Model(&models.SharedLink{}).
+		Where("file_id IN (?)", db.Unscoped().Model(&models.File{}).Select("id").Where("owner_id = ?", user.ID)).
+		Count(&sharedLinks).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count share links: %v", err)
+	}
+
+	receipt := models.DeletionReceipt{
+		ID:                 uuid.New(),
+		UserID:             user.ID,
+		EmailHash:          hashEmail(user.Email),
+		SharedLinksDeleted: sharedLinks,
+		SessionsRevoked:    sessionsRevoked,
+	}
+	if user.DeletionRequestedAt != nil {
+		receipt.RequestedAt = *user.DeletionRequestedAt
+	}
+
+	if err := export.DeleteUserExports(user.ID); err != nil {
+		return nil, err
+	}
+
+	for i := range userFiles {
+		if err := files.Purge(&userFiles[i]); err != nil {
+			return nil, err
+		}
+		receipt.FilesDeleted++
+		receipt.BytesDeleted += userFiles[i].Size
+	}
+	if err := files.CollectOwnerBlobs(user.ID); err != nil {
+		return nil, err
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("user_id = ?", user.ID).Delete(&models.APIKey{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete API keys: %v", result.Error)
+		}
+		receipt.APIKeysDeleted = result.RowsAffected
+
+		for _, model := range []interface{}{
+			&models.RefreshToken{},
+			&models.Session{},
+			&models.RecoveryCode{},
+			&models.PasswordResetToken{},
+			&models.UserIdentity{},
+		} {
+			if err := tx.Where("user_id = ?", user.ID).Delete(model).Error; err != nil {
+				return fmt.Errorf("failed to delete %T: %v", model, err)
+			}
+		}
+
+		if err := tx.Unscoped().Delete(user).Error; err != nil {
+			return fmt.Errorf("failed to delete user: %v", err)
+		}
+
+		receipt.CompletedAt = time.Now()
+		return tx.Create(&receipt).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := auth.UnlockLogin(user.Email, ""); err != nil {
+		log.Printf("⚠️ Error clearing login state for deleted account %s: %v", user.ID, err)
+	}
+
+	audit.Record(audit.Entry{
+		Action:     audit.ActionAccountPurged,
+		TargetType: "user",
+		TargetID:   user.ID.String(),
+		Details: map[string]interface{}{
+			"receipt_id":    receipt.ID,
+			"files_deleted": receipt.FilesDeleted,
+			"bytes_deleted": receipt.BytesDeleted,
+		},
+	})
+
+	err = mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Your account has been deleted",
+		Body: fmt.Sprintf("Your account has been permanently deleted.\n\nReceipt: %s\nCompleted: %s\nFiles deleted: %d (%d bytes)\nShare links deleted: %d\nSessions signed out: %d\nAPI keys deleted: %d\n",
+			receipt.ID, receipt.CompletedAt.Format(time.RFC1123), receipt.FilesDeleted, receipt.BytesDeleted,
+			receipt.SharedLinksDeleted, receipt.SessionsRevoked, receipt.APIKeysDeleted),
+	})
+	if err != nil {
+		log.Printf("Error sending deletion receipt %s: %v", receipt.ID, err)
+	}
+
+	log.Printf("🗑️ Purged account %s, receipt %s", user.ID, receipt.ID)
+	return &receipt, nil
+}
+
+func hashEmail(email string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(email)))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,65 @@
+package audit
+
+import (
+	"encoding/json"
+	"log"
+
+	"github.com/google/uuid"
+
+	"github.com/souvik150/file-sharing-app/internal/database"
+	"github.com/souvik150/file-sharing-app/internal/models"
+)
+
+const (
+	ActionLoginLockout = "auth.lockout"
+	ActionLoginUnlock  = "auth.unlock"
+
+	ActionUsersList       = "admin.users.list"
+	ActionUserRoleChange  = "admin.user.role"
+	ActionUserDisable     = "admin.user.disable"
+	ActionUserEnable      = "admin.user.enable"
+	ActionUserQuotaChange = "admin.user.quota"
+	ActionFileView        = "admin.file.view"
+	ActionFileForceDelete = "admin.file.delete"
+	ActionStorageView     = "admin.storage.view"
+	ActionAuditLogView    = "admin.audit.view"
+
+	ActionAccountDeletionRequested = "account.deletion_requested"
+	ActionAccountDeletionCancelled = "account.deletion_cancelled"
+	ActionAccountPurged            = "account.purged"
+)
+
+type Entry struct {
+	ActorID    *uuid.UUID
+	Action     string
+	TargetType string
+	TargetID   string
+	IPAddress  string
+	Details    map[string]interface{}
+}
+
+// Record writes an audit entry. Failures are logged rather than returned so
+// that auditing never breaks the action being audited.
+func Record(entry Entry) {
+	details := ""
+	if entry.Details != nil {
+		data, err := json.Marshal(entry.Details)
+		if err != nil {
+			log.Printf("Error encoding audit details for %s: %v", entry.Action, err)
+		} else {
+			details = string(data)
+		}
+	}
+
+	auditLog := models.AuditLog{
+		ActorID:    entry.ActorID,
+		Action:     entry.Action,
+		TargetType: entry.TargetType,
+		TargetID:   entry.TargetID,
+		IPAddress:  entry.IPAddress,
+		Details:    details,
+	}
+	if err := database.GetDB().Create(&auditLog).Error; err != nil {
+		log.Printf("Error writing audit entry %s: %v", entry.Action, err)
+	}
+}
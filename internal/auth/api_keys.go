@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/souvik150/file-sharing-app/internal/database"
+	"github.com/souvik150/file-sharing-app/internal/models"
+)
+
+const (
+	ScopeFilesRead   = "files:read"
+	ScopeFilesWrite  = "files:write"
+	ScopeShareCreate = "share:create"
+)
+
+// AllScopes are granted to interactive sessions and are the only scopes an
+// API key may be created with.
+var AllScopes = []string{ScopeFilesRead, ScopeFilesWrite, ScopeShareCreate}
+
+// APIKeyPrefix marks a bearer credential as an API key rather than a JWT.
+const APIKeyPrefix = "fsk_"
+
+const apiKeyTouchInterval = time.Minute
+
+var ErrAPIKeyInvalid = errors.New("API key is invalid, expired or revoked")
+var ErrUnknownScope = errors.New("unknown scope")
+
+func IsAPIKey(credential string) bool {
+	return strings.HasPrefix(credential, APIKeyPrefix)
+}
+
+// ValidateScopes de-duplicates the requested scopes and rejects unknown ones.
+func ValidateScopes(scopes []string) ([]string, error) {
+	seen := map[string]bool{}
+	var valid []string
+	for _, scope := range scopes {
+		if !slices.Contains(AllScopes, scope) {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownScope, scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			valid = append(valid, scope)
+		}
+	}
+
+	return valid, nil
+}
+
+// CreateAPIKey stores a new key and returns it in clear. This is the only time
+// the raw key is available.
+func CreateAPIKey(userID uuid.UUID, name string, scopes []string, expiresAt *time.Time) (string, *models.APIKey, error) {
+	secret, err := generateOpaqueToken()
+	if err != nil {
+		return "", nil, err
+	}
+	rawKey := APIKeyPrefix + secret
+
+	apiKey := models.APIKey{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Name:      name,
+		Prefix:    rawKey[:len(APIKeyPrefix)+6],
+		KeyHash:   hashToken(rawKey),
+		Scopes:    strings.Join(scopes, ","),
+		ExpiresAt: expiresAt,
+	}
+	if err := database.GetDB().Create(&apiKey).Error; err != nil {
+		return "", nil, fmt.Errorf("failed to store API key: %v", err)
+	}
+
+	return rawKey, &apiKey, nil
+}
+
+// AuthenticateAPIKey resolves a raw key to its record and records its use.
+func AuthenticateAPIKey(rawKey string) (*models.APIKey, error) {
+	db := database.GetDB()
+
+	var apiKey models.APIKey
+	if err := db.Preload("User").Where("key_hash = ?", hashToken(rawKey)).First(&apiKey).Error; err != nil {
+		return nil, ErrAPIKeyInvalid
+	}
+
+	now := time.Now()
+	if apiKey.RevokedAt != nil || (apiKey.ExpiresAt != nil && now.After(*apiKey.ExpiresAt)) || apiKey.User.DisabledAt != nil {
+		return nil, ErrAPIKeyInvalid
+	}
+
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) > apiKeyTouchInterval {
+		db.Model(&apiKey).Update("last_used_at", now)
+	}
+
+	return &apiKey, nil
+}
+
+func APIKeyScopes(apiKey *models.APIKey) []string {
+	if apiKey.Scopes == "" {
+		return []string{}
+	}
+	return strings.Split(apiKey.Scopes, ",")
+}
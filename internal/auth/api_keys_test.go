@@ -0,0 +1,36 @@
+package auth
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestValidateScopes(t *testing.T) {
+	tests := []struct {
+		name    string
+		scopes  []string
+		want    []string
+		wantErr error
+	}{
+		{name: "none", scopes: nil, want: nil},
+		{name: "single", scopes: []string{ScopeFilesRead}, want: []string{ScopeFilesRead}},
+		{name: "all", scopes: AllScopes, want: AllScopes},
+		{name: "duplicates dropped in order", scopes: []string{ScopeShareCreate, ScopeFilesRead, ScopeShareCreate}, want: []string{ScopeShareCreate, ScopeFilesRead}},
+		{name: "unknown", scopes: []string{ScopeFilesRead, "files:admin"}, wantErr: ErrUnknownScope},
+		{name: "case sensitive", scopes: []string{"FILES:READ"}, wantErr: ErrUnknownScope},
+		{name: "empty scope", scopes: []string{""}, wantErr: ErrUnknownScope},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ValidateScopes(tt.scopes)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/souvik150/file-sharing-app/internal/cache"
+	appConfig "github.com/souvik150/file-sharing-app/internal/config"
+)
+
+func revokedTokenKey(tokenID string) string {
+	return "revoked_token:" + tokenID
+}
+
+func revokedFamilyKey(familyID string) string {
+	return "revoked_family:" + familyID
+}
+
+// denyAccessToken keeps a single access token on the denylist until it would
+// have expired anyway.
+func denyAccessToken(tokenID string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+
+	return cache.GetClient().Set(cache.Ctx, revokedTokenKey(tokenID), 1, ttl).Err()
+}
+
+// denyFamily blocks every outstanding access token of a family. Access tokens
+// are short lived, so the entry only needs to outlive the longest one.
+func denyFamily(familyID string) error {
+	ttl := appConfig.AppConfig.AccessTokenTTL
+	return cache.GetClient().Set(cache.Ctx, revokedFamilyKey(familyID), 1, ttl).Err()
+}
+
+func isRevoked(claims *AccessClaims) (bool, error) {
+	count, err := cache.GetClient().Exists(cache.Ctx,
+		revokedTokenKey(claims.TokenID),
+		revokedFamilyKey(claims.FamilyID),
+	).Result()
+	if err != nil && err != redis.Nil {
+		return false, fmt.Errorf("failed to check token denylist: %v", err)
+	}
+
+	return count > 0, nil
+}
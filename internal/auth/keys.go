@@ -0,0 +1,213 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	appConfig "github.com/souvik150/file-sharing-app/internal/config"
+)
+
+// signingKey is one entry of the JWT keyset. Keys without a private half are
+// kept only to verify tokens issued before a rotation.
+type signingKey struct {
+	ID         string
+	Method     jwt.SigningMethod
+	PrivateKey crypto.Signer
+	PublicKey  crypto.PublicKey
+}
+
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+var keyset = map[string]*signingKey{}
+var activeKey *signingKey
+
+// LoadSigningKeys reads every PEM file in JWT_SIGNING_KEYS_DIR into the keyset,
+// using the file name without extension as the key ID. RSA keys sign with
+// RS256 and Ed25519 keys with EdDSA. To rotate, add a new key, point
+// JWT_ACTIVE_KEY_ID at it and keep the old file until its tokens expire.
+// Without a key directory, a throwaway key is generated only when
+// JWT_ALLOW_EPHEMERAL_KEY is set for development.
+func LoadSigningKeys() {
+	keysDir := appConfig.AppConfig.JWTSigningKeysDir
+	if keysDir == "" {
+		if !appConfig.AppConfig.JWTAllowEphemeralKey {
+			log.Fatal("JWT_SIGNING_KEYS_DIR is not set and JWT_ALLOW_EPHEMERAL_KEY is off")
+		}
+		log.Println("⚠️ JWT_ALLOW_EPHEMERAL_KEY is set, generating an ephemeral signing key. Tokens will not survive a restart.")
+		_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			log.Fatalf("Failed to generate JWT signing key: %v", err)
+		}
+		key := &signingKey{
+			ID:         "ephemeral",
+			Method:     jwt.SigningMethodEdDSA,
+			PrivateKey: privateKey,
+			PublicKey:  privateKey.Public(),
+		}
+		keyset[key.ID] = key
+		activeKey = key
+		return
+	}
+
+	paths, err := filepath.Glob(filepath.Join(keysDir, "*.pem"))
+	if err != nil {
+		log.Fatalf("Failed to list JWT signing keys: %v", err)
+	}
+
+	for _, path := range paths {
+		key, err := loadSigningKey(path)
+		if err != nil {
+			log.Fatalf("Failed to load JWT signing key %s: %v", path, err)
+		}
+		keyset[key.ID] = key
+	}
+
+	activeKey = keyset[appConfig.AppConfig.JWTActiveKeyID]
+	if activeKey == nil || activeKey.PrivateKey == nil {
+		log.Fatalf("JWT_ACTIVE_KEY_ID %q does not name a private key in %s", appConfig.AppConfig.JWTActiveKeyID, keysDir)
+	}
+
+	log.Printf("🔑 Loaded %d JWT signing key(s), active key: %s", len(keyset), activeKey.ID)
+}
+
+func loadSigningKey(path string) (*signingKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found")
+	}
+
+	key := &signingKey{ID: strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))}
+
+	var parsed interface{}
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Method, key.PrivateKey, key.PublicKey = jwt.SigningMethodRS256, k, k.Public()
+	case ed25519.PrivateKey:
+		key.Method, key.PrivateKey, key.PublicKey = jwt.SigningMethodEdDSA, k, k.Public()
+	case *rsa.PublicKey:
+		key.Method, key.PublicKey = jwt.SigningMethodRS256, k
+	case ed25519.PublicKey:
+		key.Method, key.PublicKey = jwt.SigningMethodEdDSA, k
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+
+	return key, nil
+}
+
+// signToken signs claims with the active key and stamps its ID into the header.
+func signToken(claims jwt.MapClaims) (string, error) {
+	token := jwt.NewWithClaims(activeKey.Method, claims)
+	token.Header["kid"] = activeKey.ID
+	return token.SignedString(activeKey.PrivateKey)
+}
+
+// parseToken verifies a token against the key named by its kid header. The
+// algorithm must match the one that key is registered for, which rules out
+// "none" and HMAC-with-public-key confusion.
+func parseToken(tokenString string) (jwt.MapClaims, error) {
+	var key *signingKey
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key = keyset[kid]
+		if key == nil {
+			return nil, fmt.Errorf("unknown key ID %q", kid)
+		}
+		if token.Method.Alg() != key.Method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return key.PublicKey, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithIssuer(appConfig.AppConfig.BackendURL),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil || !token.Valid {
+		return nil, ErrInvalidToken
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+// PublicJWKS returns the public half of every key in the keyset.
+func PublicJWKS() JWKS {
+	ids := make([]string, 0, len(keyset))
+	for id := range keyset {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	jwks := JWKS{Keys: []JWK{}}
+	for _, id := range ids {
+		key := keyset[id]
+		jwk := JWK{KeyID: key.ID, Use: "sig", Algorithm: key.Method.Alg()}
+
+		switch pub := key.PublicKey.(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		default:
+			continue
+		}
+
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	return jwks
+}
@@ -0,0 +1,324 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+
+	"github.com/souvik150/file-sharing-app/internal/cache"
+	appConfig "github.com/souvik150/file-sharing-app/internal/config"
+	"github.com/souvik150/file-sharing-app/internal/database"
+	"github.com/souvik150/file-sharing-app/internal/models"
+	"github.com/souvik150/file-sharing-app/pkg/totp"
+	"github.com/souvik150/file-sharing-app/pkg/utils"
+)
+
+const recoveryCodeCount = 10
+
+var ErrMFAAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+var ErrMFANotEnrolled = errors.New("two-factor authentication enrollment has not been started")
+var ErrMFANotEnabled = errors.New("two-factor authentication is not enabled")
+var ErrMFACodeInvalid = errors.New("invalid two-factor code")
+var ErrChallengeInvalid = errors.New("login challenge is invalid or expired")
+
+func encryptSecret(secret string) (string, error) {
+	encrypted, err := utils.Encrypt([]byte(secret), []byte(appConfig.AppConfig.EncryptionKey))
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(encrypted), nil
+}
+
+func decryptSecret(stored string) (string, error) {
+	encrypted, err := base64.StdEncoding.DecodeString(stored)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode TOTP secret: %v", err)
+	}
+	secret, err := utils.Decrypt(encrypted, []byte(appConfig.AppConfig.EncryptionKey))
+	if err != nil {
+		return "", err
+	}
+	return string(secret), nil
+}
+
+// BeginTOTPEnrollment generates a new secret for the user and returns it with
+// the matching otpauth URI. The secret is not enforced until confirmed.
+func BeginTOTPEnrollment(user *models.User) (string, string, error) {
+	if user.TOTPEnabledAt != nil {
+		return "", "", ErrMFAAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return "", "", err
+	}
+
+	encrypted, err := encryptSecret(secret)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encrypt TOTP secret: %v", err)
+	}
+
+	if err := database.GetDB().Model(user).Update("totp_secret", encrypted).Error; err != nil {
+		return "", "", fmt.Errorf("failed to store TOTP secret: %v", err)
+	}
+
+	return secret, totp.URI(appConfig.AppConfig.TOTPIssuer, user.Email, secret), nil
+}
+
+// ConfirmTOTPEnrollment enables two-factor authentication once the user proves
+// their authenticator works, and returns a fresh set of recovery codes.
+func ConfirmTOTPEnrollment(user *models.User, code string, sessionID uuid.UUID) ([]string, error) {
+	if user.TOTPEnabledAt != nil {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, ErrMFANotEnrolled
+	}
+
+	if err := verifyTOTP(user, code); err != nil {
+		return nil, err
+	}
+
+	var codes []string
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Update("totp_enabled_at", time.Now()).Error; err != nil {
+			return fmt.Errorf("failed to enable two-factor authentication: %v", err)
+		}
+
+		var err error
+		codes, err = replaceRecoveryCodes(tx, user.ID)
+		if err != nil {
+			return err
+		}
+
+		return markSessionMFA(tx, sessionID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+func DisableTOTP(user *models.User) error {
+	if user.TOTPEnabledAt == nil {
+		return ErrMFANotEnabled
+	}
+
+	return database.GetDB().Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(user).Updates(map[string]interface{}{
+			"totp_secret":         "",
+			"totp_enabled_at":     nil,
+			"totp_last_used_step": 0,
+		}).Error
+		if err != nil {
+			return fmt.Errorf("failed to disable two-factor authentication: %v", err)
+		}
+
+		return tx.Where("user_id = ?", user.ID).Delete(&models.RecoveryCode{}).Error
+	})
+}
+
+func RegenerateRecoveryCodes(user *models.User) ([]string, error) {
+	if user.TOTPEnabledAt == nil {
+		return nil, ErrMFANotEnabled
+	}
+
+	var codes []string
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		var err error
+		codes, err = replaceRecoveryCodes(tx, user.ID)
+		return err
+	})
+	return codes, err
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, userID uuid.UUID) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return nil, fmt.Errorf("failed to remove old recovery codes: %v", err)
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		buf := make([]byte, 6)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, fmt.Errorf("failed to generate recovery code: %v", err)
+		}
+		raw := strings.ToLower(fmt.Sprintf("%x", buf))
+		code := raw[:6] + "-" + raw[6:]
+
+		hash, err := bcrypt.GenerateFromPassword([]byte(code), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash recovery code: %v", err)
+		}
+		if err := tx.Create(&models.RecoveryCode{UserID: userID, CodeHash: string(hash)}).Error; err != nil {
+			return nil, fmt.Errorf("failed to store recovery code: %v", err)
+		}
+
+		codes = append(codes, code)
+	}
+
+	return codes, nil
+}
+
+// verifyTOTP checks a code and burns its time step so it cannot be replayed.
+func verifyTOTP(user *models.User, code string) error {
+	secret, err := decryptSecret(user.TOTPSecret)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt TOTP secret: %v", err)
+	}
+
+	step, ok := totp.Validate(secret, strings.TrimSpace(code), time.Now())
+	if !ok {
+		return ErrMFACodeInvalid
+	}
+
+	result := database.GetDB().Model(&models.User{}).
+		Where("id = ? AND totp_last_used_step < ?", user.ID, step).
+		Update("totp_last_used_step", step)
+	if result.Error != nil {
+		return fmt.Errorf("failed to record TOTP use: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrMFACodeInvalid
+	}
+
+	return nil
+}
+
+func useRecoveryCode(user *models.User, code string) error {
+	code = strings.ToLower(strings.TrimSpace(code))
+
+	var recoveryCodes []models.RecoveryCode
+	if err := database.GetDB().Where("user_id = ? AND used_at IS NULL", user.ID).Find(&recoveryCodes).Error; err != nil {
+		return fmt.Errorf("failed to load recovery codes: %v", err)
+	}
+
+	for _, recoveryCode := range recoveryCodes {
+		if bcrypt.CompareHashAndPassword([]byte(recoveryCode.CodeHash), []byte(code)) != nil {
+			continue
+		}
+
+		result := database.GetDB().Model(&models.RecoveryCode{}).
+			Where("id = ? AND used_at IS NULL", recoveryCode.ID).
+			Update("used_at", time.Now())
+		if result.Error != nil {
+			return fmt.Errorf("failed to consume recovery code: %v", result.Error)
+		}
+		if result.RowsAffected == 1 {
+			return nil
+		}
+	}
+
+	return ErrMFACodeInvalid
+}
+
+// VerifySecondFactor accepts either a TOTP code or an unused recovery code.
+func VerifySecondFactor(user *models.User, code, recoveryCode string) error {
+	if user.TOTPEnabledAt == nil {
+		return ErrMFANotEnabled
+	}
+	if recoveryCode != "" {
+		return useRecoveryCode(user, recoveryCode)
+	}
+	return verifyTOTP(user, code)
+}
+
+// IssueMFAChallenge returns a short-lived token proving the first login step
+// succeeded. It cannot be used as an access token.
+func IssueMFAChallenge(userID uuid.UUID) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(appConfig.AppConfig.MFAChallengeTTL)
+
+	token, err := signToken(jwt.MapClaims{
+		"iss": appConfig.AppConfig.BackendURL,
+		"sub": userID.String(),
+		"typ": "mfa_challenge",
+		"jti": uuid.New().String(),
+		"iat": now.Unix(),
+		"exp": expiresAt.Unix(),
+	})
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to sign login challenge: %v", err)
+	}
+
+	return token, expiresAt, nil
+}
+
+// ConsumeMFAChallenge validates a challenge token and returns the user it was
+// issued for. Each challenge is accepted once.
+func ConsumeMFAChallenge(challenge string) (uuid.UUID, error) {
+	claims, err := parseToken(challenge)
+	if err != nil {
+		return uuid.Nil, ErrChallengeInvalid
+	}
+
+	if typ, _ := claims["typ"].(string); typ != "mfa_challenge" {
+		return uuid.Nil, ErrChallengeInvalid
+	}
+
+	subject, _ := claims["sub"].(string)
+	userID, err := uuid.Parse(subject)
+	if err != nil {
+		return uuid.Nil, ErrChallengeInvalid
+	}
+
+	jti, _ := claims["jti"].(string)
+	fresh, err := cache.GetClient().SetNX(cache.Ctx, "used_mfa_challenge:"+jti, 1, appConfig.AppConfig.MFAChallengeTTL).Result()
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to record login challenge: %v", err)
+	}
+	if !fresh {
+		return uuid.Nil, ErrChallengeInvalid
+	}
+
+	return userID, nil
+}
+
+func markSessionMFA(tx *gorm.DB, sessionID uuid.UUID) error {
+	err := tx.Model(&models.Session{}).Where("id = ?", sessionID).Update("mfa_verified_at", time.Now()).Error
+	if err != nil {
+		return fmt.Errorf("failed to record second factor on session: %v", err)
+	}
+	return nil
+}
+
+// StepUp records a fresh second factor on the current session and issues an
+// access token that carries it.
+func StepUp(claims *AccessClaims) (string, time.Time, error) {
+	sessionID, err := uuid.Parse(claims.FamilyID)
+	if err != nil {
+		return "", time.Time{}, ErrInvalidToken
+	}
+
+	db := database.GetDB()
+	if err := markSessionMFA(db, sessionID); err != nil {
+		return "", time.Time{}, err
+	}
+
+	return issueSessionAccessToken(db, uuid.MustParse(claims.UserID), sessionID)
+}
+
+// HasRecentMFA reports whether the request may perform a sensitive action:
+// users without two-factor authentication pass, everyone else must have
+// presented a second factor within MFA_RECENT_WINDOW.
+func HasRecentMFA(claims *AccessClaims) (bool, error) {
+	var user models.User
+	if err := database.GetDB().Select("totp_enabled_at").Where("id = ?", claims.UserID).First(&user).Error; err != nil {
+		return false, fmt.Errorf("failed to load user: %v", err)
+	}
+
+	if user.TOTPEnabledAt == nil {
+		return true, nil
+	}
+
+	return claims.MFAAt != nil && time.Since(*claims.MFAAt) <= appConfig.AppConfig.MFARecentWindow, nil
+}
@@ -0,0 +1,244 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/oauth2"
+	"gorm.io/gorm"
+
+	"github.com/souvik150/file-sharing-app/internal/cache"
+	appConfig "github.com/souvik150/file-sharing-app/internal/config"
+	"github.com/souvik150/file-sharing-app/internal/database"
+	"github.com/souvik150/file-sharing-app/internal/models"
+)
+
+// OIDCStateTTL bounds how long a login may take at the identity provider.
+const OIDCStateTTL = 10 * time.Minute
+
+var ErrOIDCDisabled = errors.New("single sign-on is not configured")
+var ErrOIDCStateInvalid = errors.New("login state is invalid or expired")
+var ErrOIDCEmailUnverified = errors.New("identity provider did not return a verified email")
+var ErrOIDCAccountUnavailable = errors.New("the linked account has been deleted")
+
+var oidcProvider *oidc.Provider
+var oidcVerifier *oidc.IDTokenVerifier
+var oauthConfig *oauth2.Config
+
+// oidcState is what we remember between redirecting to the identity provider
+// and handling its callback.
+type oidcState struct {
+	Verifier string `json:"verifier"`
+	Nonce    string `json:"nonce"`
+}
+
+type oidcClaims struct {
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Nonce         string `json:"nonce"`
+}
+
+// SetupOIDC discovers the configured identity provider. Single sign-on stays
+// disabled when OIDC_ISSUER_URL is empty.
+func SetupOIDC() {
+	if appConfig.AppConfig.OIDCIssuerURL == "" {
+		return
+	}
+
+	provider, err := oidc.NewProvider(context.Background(), appConfig.AppConfig.OIDCIssuerURL)
+	if err != nil {
+		log.Fatalf("Failed to discover OIDC provider: %v", err)
+	}
+
+	oidcProvider = provider
+	oidcVerifier = provider.Verifier(&oidc.Config{ClientID: appConfig.AppConfig.OIDCClientID})
+	oauthConfig = &oauth2.Config{
+		ClientID:     appConfig.AppConfig.OIDCClientID,
+		ClientSecret: appConfig.AppConfig.OIDCClientSecret,
+		RedirectURL:  appConfig.AppConfig.OIDCRedirectURL,
+		Endpoint:     provider.Endpoint(),
+		Scopes:       []string{oidc.ScopeOpenID, "email", "profile"},
+	}
+
+	log.Printf("🔐 Single sign-on enabled with %s", appConfig.AppConfig.OIDCIssuerURL)
+}
+
+// BeginOIDCLogin returns the URL to send the user to along with the state the
+// callback must carry. The request uses the authorization code flow with PKCE
+// and a nonce bound to the ID token. Callers must also bind the state to the
+// browser, otherwise an attacker can complete their own login in a victim's
+// browser.
+func BeginOIDCLogin() (string, string, error) {
+	if oidcProvider == nil {
+		return "", "", ErrOIDCDisabled
+	}
+
+	state, err := generateOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := generateOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	data, err := json.Marshal(oidcState{Verifier: verifier, Nonce: nonce})
+	if err != nil {
+		return "", "", err
+	}
+	if err := cache.GetClient().Set(cache.Ctx, "oidc_state:"+state, data, OIDCStateTTL).Err(); err != nil {
+		return "", "", fmt.Errorf("failed to store login state: %v", err)
+	}
+
+	return oauthConfig.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier)), state, nil
+}
+
+// CompleteOIDCLogin exchanges the authorization code, verifies the ID token and
+// resolves it to a local user, linking or provisioning one as needed.
+func CompleteOIDCLogin(ctx context.Context, state, code string) (*models.User, error) {
+	if oidcProvider == nil {
+		return nil, ErrOIDCDisabled
+	}
+
+	// GetDel makes the state single-use.
+	data, err := cache.GetClient().GetDel(cache.Ctx, "oidc_state:"+state).Bytes()
+	if err != nil {
+		return nil, ErrOIDCStateInvalid
+	}
+	var saved oidcState
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, ErrOIDCStateInvalid
+	}
+
+	token, err := oauthConfig.Exchange(ctx, code, oauth2.VerifierOption(saved.Verifier))
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange authorization code: %v", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, fmt.Errorf("token response did not include an id_token")
+	}
+
+	idToken, err := oidcVerifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify id_token: %v", err)
+	}
+
+	var claims oidcClaims
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("failed to parse id_token claims: %v", err)
+	}
+	if claims.Nonce != saved.Nonce {
+		return nil, ErrOIDCStateInvalid
+	}
+
+	return resolveOIDCUser(idToken.Issuer, idToken.Subject, claims)
+}
+
+func resolveOIDCUser(issuer, subject string, claims oidcClaims) (*models.User, error) {
+	db := database.GetDB()
+	now := time.Now()
+
+	var identity models.UserIdentity
+	err := db.Preload("User").Where("issuer = ? AND subject = ?", issuer, subject).First(&identity).Error
+	if err == nil {
+		// Preload skips soft-deleted users and leaves a zero value behind.
+		if identity.User.ID == uuid.Nil || deletionDue(&identity.User, now) {
+			return nil, ErrOIDCAccountUnavailable
+		}
+		db.Model(&identity).Update("last_login_at", now)
+		return &identity.User, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to look up identity: %v", err)
+	}
+
+	// Only a verified email may be used to link to, or create, an account,
+	// otherwise anyone could claim an existing user's address at the IdP.
+	if claims.Email == "" || !claims.EmailVerified {
+		return nil, ErrOIDCEmailUnverified
+	}
+	email := strings.ToLower(claims.Email)
+
+	var user models.User
+	err = db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("LOWER(email) = ?", email).First(&user).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			user, err = provisionOIDCUser(tx, email)
+			if err != nil {
+				return err
+			}
+			log.Printf("👤 Provisioned user %s from %s", user.ID, issuer)
+		case err != nil:
+			return fmt.Errorf("failed to look up user: %v", err)
+		case deletionDue(&user, now):
+			return ErrOIDCAccountUnavailable
+		case user.EmailVerifiedAt == nil:
+			if err := tx.Model(&user).Update("email_verified_at", now).Error; err != nil {
+				return fmt.Errorf("failed to mark email as verified: %v", err)
+			}
+		}
+
+		identity = models.UserIdentity{
+			UserID:      user.ID,
+			Issuer:      issuer,
+			Subject:     subject,
+			Email:       email,
+			LastLoginAt: now,
+		}
+		if err := tx.Create(&identity).Error; err != nil {
+			return fmt.Errorf("failed to link identity: %v", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// deletionDue reports whether the user's account deletion grace period is
+// over. Until then the account keeps working so the deletion can be cancelled,
+// just as with a password login.
+func deletionDue(user *models.User, now time.Time) bool {
+	return user.DeletionScheduledAt != nil && !user.DeletionScheduledAt.After(now)
+}
+
+// provisionOIDCUser creates an account for a first-time SSO user. It gets a
+// random password nobody knows; a password can be set later through reset.
+func provisionOIDCUser(tx *gorm.DB, email string) (models.User, error) {
+	randomPassword, err := generateOpaqueToken()
+	if err != nil {
+		return models.User{}, err
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(randomPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return models.User{}, fmt.Errorf("failed to hash password: %v", err)
+	}
+
+	now := time.Now()
+	user := models.User{
+		ID:              uuid.New(),
+		Email:           email,
+		Password:        string(hashedPassword),
+		EmailVerifiedAt: &now,
+	}
+	if err := tx.Create(&user).Error; err != nil {
+		return models.User{}, fmt.Errorf("failed to create user: %v", err)
+	}
+
+	return user, nil
+}
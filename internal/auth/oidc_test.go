@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/souvik150/file-sharing-app/internal/database"
+	"github.com/souvik150/file-sharing-app/internal/models"
+	"github.com/souvik150/file-sharing-app/internal/testenv"
+)
+
+func TestResolveOIDCUserRefusesUnavailableAccounts(t *testing.T) {
+	testenv.Setup(t)
+
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name   string
+		update func(user *models.User) error
+		want   error
+	}{
+		{name: "active account", update: func(*models.User) error { return nil }, want: nil},
+		{name: "deletion pending", update: func(user *models.User) error {
+			return database.GetDB().Model(user).Update("deletion_scheduled_at", future).Error
+		}, want: nil},
+		{name: "deletion due", update: func(user *models.User) error {
+			return database.GetDB().Model(user).Update("deletion_scheduled_at", past).Error
+		}, want: ErrOIDCAccountUnavailable},
+		{name: "soft deleted", update: func(user *models.User) error {
+			return database.GetDB().Delete(user).Error
+		}, want: ErrOIDCAccountUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := testenv.CreateUser(t)
+			identity := models.UserIdentity{
+				UserID:      user.ID,
+				Issuer:      "https://idp.example.com",
+				Subject:     user.ID.String(),
+				Email:       user.Email,
+				LastLoginAt: time.Now(),
+			}
+			if err := database.GetDB().Create(&identity).Error; err != nil {
+				t.Fatalf("failed to create identity: %v", err)
+			}
+			if err := tt.update(&user); err != nil {
+				t.Fatalf("failed to update user: %v", err)
+			}
+
+			resolved, err := resolveOIDCUser(identity.Issuer, identity.Subject, oidcClaims{Email: user.Email, EmailVerified: true})
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("got error %v, want %v", err, tt.want)
+			}
+			if err == nil && resolved.ID != user.ID {
+				t.Errorf("resolved user %s, want %s", resolved.ID, user.ID)
+			}
+		})
+	}
+}
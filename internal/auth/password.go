@@ -0,0 +1,227 @@
+package auth
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/mail"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+
+	appConfig "github.com/souvik150/file-sharing-app/internal/config"
+	"github.com/souvik150/file-sharing-app/internal/database"
+	"github.com/souvik150/file-sharing-app/internal/models"
+)
+
+// bcrypt ignores everything past 72 bytes, so longer passwords would give a
+// false sense of security.
+const maxPasswordBytes = 72
+
+var ErrInvalidEmail = errors.New("invalid email address")
+var ErrWrongPassword = errors.New("current password is incorrect")
+
+// PasswordPolicyError lists every rule a rejected password breaks.
+type PasswordPolicyError struct {
+	Problems []string
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return "password " + strings.Join(e.Problems, "; ")
+}
+
+// breachedHashes indexes SHA-1 hashes of known breached passwords by their
+// five character prefix, the same bucketing the k-anonymity range APIs use.
+var breachedHashes = map[string]map[string]struct{}{}
+
+// LoadBreachedPasswords reads BREACHED_PASSWORDS_FILE, one uppercase SHA-1
+// hash per line with an optional ":count" suffix as in the Have I Been Pwned
+// downloads. Without a file the breached password check is skipped.
+func LoadBreachedPasswords() {
+	path := appConfig.AppConfig.BreachedPasswordsFile
+	if path == "" {
+		return
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		log.Fatalf("Failed to open breached password list: %v", err)
+	}
+	defer file.Close()
+
+	count := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		hash, _, _ := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if len(hash) != sha1.Size*2 {
+			continue
+		}
+		hash = strings.ToUpper(hash)
+
+		prefix, suffix := hash[:5], hash[5:]
+		if breachedHashes[prefix] == nil {
+			breachedHashes[prefix] = map[string]struct{}{}
+		}
+		breachedHashes[prefix][suffix] = struct{}{}
+		count++
+	}
+	if err := scanner.Err(); err != nil {
+		log.Fatalf("Failed to read breached password list: %v", err)
+	}
+
+	log.Printf("🛡️ Loaded %d breached password hashes", count)
+}
+
+func isBreached(password string) bool {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	_, found := breachedHashes[hash[:5]][hash[5:]]
+	return found
+}
+
+// NormalizeEmail validates an email address and returns it lowercased.
+func NormalizeEmail(email string) (string, error) {
+	email = strings.TrimSpace(email)
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email || address.Name != "" {
+		return "", ErrInvalidEmail
+	}
+
+	_, domain, _ := strings.Cut(email, "@")
+	if !strings.Contains(domain, ".") || strings.HasPrefix(domain, ".") || strings.HasSuffix(domain, ".") {
+		return "", ErrInvalidEmail
+	}
+
+	return strings.ToLower(email), nil
+}
+
+// ValidatePassword applies the password policy for the account with the given
+// email and returns a *PasswordPolicyError if the password is rejected.
+func ValidatePassword(password, email string) error {
+	config := appConfig.AppConfig
+	var problems []string
+
+	if len([]rune(password)) < config.PasswordMinLength {
+		problems = append(problems, fmt.Sprintf("must be at least %d characters long", config.PasswordMinLength))
+	}
+	if len(password) > maxPasswordBytes {
+		problems = append(problems, fmt.Sprintf("must be at most %d bytes long", maxPasswordBytes))
+	}
+
+	localPart, _, _ := strings.Cut(strings.ToLower(email), "@")
+	if len(localPart) >= 3 && strings.Contains(strings.ToLower(password), localPart) {
+		problems = append(problems, "must not contain your email address")
+	}
+
+	if entropy := estimateEntropy(password); entropy < config.PasswordMinEntropy {
+		problems = append(problems, "is too easy to guess, use a longer mix of words, numbers and symbols")
+	}
+
+	if isBreached(password) {
+		problems = append(problems, "has appeared in a data breach, choose a different one")
+	}
+
+	if len(problems) > 0 {
+		return &PasswordPolicyError{Problems: problems}
+	}
+	return nil
+}
+
+// estimateEntropy gives a rough strength score in bits: the size of the
+// character classes used, applied only to characters that do not simply
+// repeat or continue a sequence from the previous one.
+func estimateEntropy(password string) float64 {
+	var lower, upper, digit, symbol bool
+	effectiveLength := 0
+	var previous rune
+
+	for i, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+
+		if i == 0 || (r != previous && r != previous+1 && r != previous-1) {
+			effectiveLength++
+		}
+		previous = r
+	}
+
+	pool := 0
+	if lower {
+		pool += 26
+	}
+	if upper {
+		pool += 26
+	}
+	if digit {
+		pool += 10
+	}
+	if symbol {
+		pool += 33
+	}
+	if pool == 0 {
+		return 0
+	}
+
+	return float64(effectiveLength) * math.Log2(float64(pool))
+}
+
+func HashPassword(password string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %v", err)
+	}
+	return string(hashed), nil
+}
+
+// VerifyPassword re-checks the password of an already authenticated user
+// before a sensitive change.
+func VerifyPassword(user *models.User, password string) error {
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)) != nil {
+		return ErrWrongPassword
+	}
+	return nil
+}
+
+// ChangePassword re-checks the current password, stores the new one and
+// revokes every session except the one making the change.
+func ChangePassword(userID, keepSessionID uuid.UUID, currentPassword, newPassword string) (int, error) {
+	var user models.User
+	if err := database.GetDB().Where("id = ?", userID).First(&user).Error; err != nil {
+		return 0, fmt.Errorf("failed to load user: %v", err)
+	}
+
+	if err := VerifyPassword(&user, currentPassword); err != nil {
+		return 0, err
+	}
+
+	if err := ValidatePassword(newPassword, user.Email); err != nil {
+		return 0, err
+	}
+
+	hashedPassword, err := HashPassword(newPassword)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := database.GetDB().Model(&user).Update("password", hashedPassword).Error; err != nil {
+		return 0, fmt.Errorf("failed to update password: %v", err)
+	}
+
+	return RevokeUserSessions(userID, keepSessionID)
+}
@@ -0,0 +1,167 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	appConfig "github.com/souvik150/file-sharing-app/internal/config"
+	"github.com/souvik150/file-sharing-app/internal/database"
+	"github.com/souvik150/file-sharing-app/internal/models"
+)
+
+var ErrRefreshTokenInvalid = errors.New("refresh token is invalid or expired")
+var ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+
+func hashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+func generateOpaqueToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate token: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// IssueTokenPair opens a new session for a fresh login and issues the first
+// token pair of its refresh token family.
+func IssueTokenPair(userID uuid.UUID, meta SessionMeta) (*TokenPair, error) {
+	var pair *TokenPair
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		session, err := createSession(tx, userID, meta)
+		if err != nil {
+			return err
+		}
+
+		pair, err = issueInFamily(tx, userID, session.ID, nil)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return pair, nil
+}
+
+func issueInFamily(tx *gorm.DB, userID, familyID uuid.UUID, previous *models.RefreshToken) (*TokenPair, error) {
+	rawRefresh, err := generateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken := models.RefreshToken{
+		ID:        uuid.New(),
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(rawRefresh),
+		ExpiresAt: time.Now().Add(appConfig.AppConfig.RefreshTokenTTL),
+	}
+	if err := tx.Create(&refreshToken).Error; err != nil {
+		return nil, fmt.Errorf("failed to store refresh token: %v", err)
+	}
+
+	if previous != nil {
+		if err := tx.Model(previous).Update("replaced_by_id", refreshToken.ID).Error; err != nil {
+			return nil, fmt.Errorf("failed to link rotated refresh token: %v", err)
+		}
+	}
+
+	accessToken, accessExpiresAt, err := issueSessionAccessToken(tx, userID, familyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:      accessToken,
+		AccessExpiresAt:  accessExpiresAt,
+		RefreshToken:     rawRefresh,
+		RefreshExpiresAt: refreshToken.ExpiresAt,
+	}, nil
+}
+
+// RotateRefreshToken exchanges a refresh token for a new pair. Presenting a
+// token that was already rotated or revoked is treated as theft and kills the
+// whole family, including any access tokens still in flight.
+func RotateRefreshToken(rawRefresh string, meta SessionMeta) (*TokenPair, error) {
+	db := database.GetDB()
+
+	var current models.RefreshToken
+	if err := db.Where("token_hash = ?", hashToken(rawRefresh)).First(&current).Error; err != nil {
+		return nil, ErrRefreshTokenInvalid
+	}
+
+	if current.RevokedAt != nil {
+		log.Printf("⚠️ Refresh token reuse detected for user %s, revoking session %s", current.UserID, current.FamilyID)
+		if err := RevokeSession(current.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	}
+
+	if time.Now().After(current.ExpiresAt) {
+		return nil, ErrRefreshTokenInvalid
+	}
+
+	var pair *TokenPair
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Guard against two concurrent refreshes with the same token: only
+		// the one that flips revoked_at wins, the other counts as reuse.
+		result := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND revoked_at IS NULL", current.ID).
+			Update("revoked_at", time.Now())
+		if result.Error != nil {
+			return fmt.Errorf("failed to rotate refresh token: %v", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrRefreshTokenReused
+		}
+
+		var err error
+		pair, err = issueInFamily(tx, current.UserID, current.FamilyID, &current)
+		if err != nil {
+			return err
+		}
+
+		return extendSession(tx, current.FamilyID, pair.RefreshExpiresAt, meta)
+	})
+	if errors.Is(err, ErrRefreshTokenReused) {
+		if revokeErr := RevokeSession(current.FamilyID); revokeErr != nil {
+			return nil, revokeErr
+		}
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return pair, nil
+}
+
+// Logout revokes the session behind an access token as well as the token itself.
+func Logout(claims *AccessClaims) error {
+	sessionID, err := uuid.Parse(claims.FamilyID)
+	if err != nil {
+		return ErrInvalidToken
+	}
+
+	if err := RevokeSession(sessionID); err != nil {
+		return err
+	}
+
+	if err := denyAccessToken(claims.TokenID, claims.ExpiresAt); err != nil {
+		return fmt.Errorf("failed to denylist access token: %v", err)
+	}
+
+	return nil
+}
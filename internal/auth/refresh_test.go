@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/souvik150/file-sharing-app/internal/database"
+	"github.com/souvik150/file-sharing-app/internal/models"
+	"github.com/souvik150/file-sharing-app/internal/testenv"
+)
+
+func TestRotateRefreshTokenDetectsReuse(t *testing.T) {
+	testenv.Setup(t)
+	LoadSigningKeys()
+
+	tests := []struct {
+		name string
+		// present picks the refresh token sent on each rotation from the
+		// tokens issued so far, the first login being index 0.
+		present []int
+		want    []error
+	}{
+		{name: "each token once", present: []int{0, 1, 2}, want: []error{nil, nil, nil}},
+		{name: "rotated token replayed", present: []int{0, 0}, want: []error{nil, ErrRefreshTokenReused}},
+		{name: "family dead after reuse", present: []int{0, 0, 1}, want: []error{nil, ErrRefreshTokenReused, ErrRefreshTokenReused}},
+		{name: "older token replayed later", present: []int{0, 1, 0}, want: []error{nil, nil, ErrRefreshTokenReused}},
+		{name: "unknown token", present: []int{-1}, want: []error{ErrRefreshTokenInvalid}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := testenv.CreateUser(t)
+			meta := SessionMeta{IPAddress: "127.0.0.1", UserAgent: "test"}
+
+			first, err := IssueTokenPair(user.ID, meta)
+			if err != nil {
+				t.Fatalf("IssueTokenPair: %v", err)
+			}
+			issued := []string{first.RefreshToken}
+
+			var reused bool
+			for step, index := range tt.present {
+				token := "not-a-refresh-token"
+				if index >= 0 {
+					token = issued[index]
+				}
+				pair, err := RotateRefreshToken(token, meta)
+				if !errors.Is(err, tt.want[step]) {
+					t.Fatalf("step %d: got error %v, want %v", step, err, tt.want[step])
+				}
+				if err == nil {
+					issued = append(issued, pair.RefreshToken)
+				}
+				reused = reused || errors.Is(err, ErrRefreshTokenReused)
+			}
+
+			var session models.Session
+			if err := database.GetDB().Where("user_id = ?", user.ID).First(&session).Error; err != nil {
+				t.Fatalf("failed to load session: %v", err)
+			}
+			if reused != (session.RevokedAt != nil) {
+				t.Errorf("session revoked = %v, want %v", session.RevokedAt != nil, reused)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	appConfig "github.com/souvik150/file-sharing-app/internal/config"
+	"github.com/souvik150/file-sharing-app/internal/database"
+	"github.com/souvik150/file-sharing-app/internal/models"
+)
+
+var ErrAccountDisabled = errors.New("account is disabled")
+
+// BootstrapAdmins grants the admin role to every account listed in
+// ADMIN_EMAILS so a fresh deployment can be operated without raw SQL. Further
+// roles are managed through the admin API.
+func BootstrapAdmins() {
+	if len(appConfig.AppConfig.AdminEmails) == 0 {
+		return
+	}
+
+	result := database.GetDB().Model(&models.User{}).
+		Where("LOWER(email) IN ? AND role <> ?", appConfig.AppConfig.AdminEmails, models.RoleAdmin).
+		Update("role", models.RoleAdmin)
+	if result.Error != nil {
+		log.Fatalf("Failed to bootstrap admin accounts: %v", result.Error)
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("👑 Granted the admin role to %d account(s) from ADMIN_EMAILS", result.RowsAffected)
+	}
+}
+
+// UserRole returns the role of an enabled user. Disabled users get
+// ErrAccountDisabled.
+func UserRole(userID string) (string, error) {
+	var user models.User
+	if err := database.GetDB().Select("role", "disabled_at").Where("id = ?", userID).First(&user).Error; err != nil {
+		return "", fmt.Errorf("failed to load user: %v", err)
+	}
+
+	if user.DisabledAt != nil {
+		return "", ErrAccountDisabled
+	}
+
+	return user.Role, nil
+}
+
+// DisableUser blocks the account from logging in, signs out every session and
+// revokes its API keys.
+func DisableUser(userID uuid.UUID) (int, error) {
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		err := tx.Model(&models.User{}).
+			Where("id = ? AND disabled_at IS NULL", userID).
+			Update("disabled_at", now).Error
+		if err != nil {
+			return fmt.Errorf("failed to disable user: %v", err)
+		}
+
+		err = tx.Model(&models.APIKey{}).
+			Where("user_id = ? AND revoked_at IS NULL", userID).
+			Update("revoked_at", now).Error
+		if err != nil {
+			return fmt.Errorf("failed to revoke API keys: %v", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return RevokeUserSessions(userID, uuid.Nil)
+}
+
+// EnableUser lifts a previous DisableUser. Sessions and API keys revoked at
+// the time stay revoked.
+func EnableUser(userID uuid.UUID) error {
+	err := database.GetDB().Model(&models.User{}).Where("id = ?", userID).Update("disabled_at", nil).Error
+	if err != nil {
+		return fmt.Errorf("failed to enable user: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package auth
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/souvik150/file-sharing-app/internal/cache"
+	appConfig "github.com/souvik150/file-sharing-app/internal/config"
+	"github.com/souvik150/file-sharing-app/internal/database"
+	"github.com/souvik150/file-sharing-app/internal/models"
+)
+
+// SessionRevokedChannel is the Redis pub/sub channel that carries the IDs of
+// revoked sessions, so every instance can drop connections tied to them.
+const SessionRevokedChannel = "session_revoked"
+
+const sessionTouchInterval = time.Minute
+
+// SessionMeta describes the client a session was opened or refreshed from.
+type SessionMeta struct {
+	IPAddress string
+	UserAgent string
+	// MFAVerified is set when the login that opens the session included a
+	// second factor.
+	MFAVerified bool
+}
+
+func createSession(tx *gorm.DB, userID uuid.UUID, meta SessionMeta) (*models.Session, error) {
+	now := time.Now()
+	session := models.Session{
+		ID:         uuid.New(),
+		UserID:     userID,
+		IPAddress:  meta.IPAddress,
+		UserAgent:  meta.UserAgent,
+		CreatedAt:  now,
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(appConfig.AppConfig.RefreshTokenTTL),
+	}
+	if meta.MFAVerified {
+		session.MFAVerifiedAt = &now
+	}
+	if err := tx.Create(&session).Error; err != nil {
+		return nil, fmt.Errorf("failed to create session: %v", err)
+	}
+
+	return &session, nil
+}
+
+func extendSession(tx *gorm.DB, sessionID uuid.UUID, expiresAt time.Time, meta SessionMeta) error {
+	err := tx.Model(&models.Session{}).Where("id = ?", sessionID).Updates(map[string]interface{}{
+		"expires_at":   expiresAt,
+		"last_used_at": time.Now(),
+		"ip_address":   meta.IPAddress,
+		"user_agent":   meta.UserAgent,
+	}).Error
+	if err != nil {
+		return fmt.Errorf("failed to update session: %v", err)
+	}
+
+	return nil
+}
+
+// TouchSession records activity on a session. Writes are throttled through
+// Redis so that busy clients do not update the row on every request.
+func TouchSession(sessionID string) {
+	set, err := cache.GetClient().SetNX(cache.Ctx, "session_touch:"+sessionID, 1, sessionTouchInterval).Result()
+	if err != nil || !set {
+		return
+	}
+
+	err = database.GetDB().Model(&models.Session{}).
+		Where("id = ?", sessionID).
+		Update("last_used_at", time.Now()).Error
+	if err != nil {
+		log.Printf("Error updating session last used time: %v", err)
+	}
+}
+
+// RevokeSession revokes a session together with every refresh token in its
+// family, denylists the access tokens issued from it and announces the
+// revocation so that live WebSocket connections can be closed.
+func RevokeSession(sessionID uuid.UUID) error {
+	db := database.GetDB()
+	now := time.Now()
+
+	err := db.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", sessionID).
+		Update("revoked_at", now).Error
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %v", err)
+	}
+
+	err = db.Model(&models.Session{}).
+		Where("id = ? AND revoked_at IS NULL", sessionID).
+		Update("revoked_at", now).Error
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %v", err)
+	}
+
+	if err := denyFamily(sessionID.String()); err != nil {
+		return fmt.Errorf("failed to denylist session: %v", err)
+	}
+
+	if err := cache.GetClient().Publish(cache.Ctx, SessionRevokedChannel, sessionID.String()).Err(); err != nil {
+		log.Printf("Error publishing session revocation: %v", err)
+	}
+
+	return nil
+}
+
+// RevokeUserSessions revokes every active session of a user except the one
+// given in keep, which may be uuid.Nil to revoke them all.
+func RevokeUserSessions(userID, keep uuid.UUID) (int, error) {
+	var sessionIDs []uuid.UUID
+	err := database.GetDB().Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL AND id <> ?", userID, keep).
+		Pluck("id", &sessionIDs).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to list sessions: %v", err)
+	}
+
+	for _, sessionID := range sessionIDs {
+		if err := RevokeSession(sessionID); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(sessionIDs), nil
+}
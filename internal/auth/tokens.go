@@ -0,0 +1,124 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	appConfig "github.com/souvik150/file-sharing-app/internal/config"
+	"github.com/souvik150/file-sharing-app/internal/models"
+)
+
+var ErrInvalidToken = errors.New("invalid token")
+var ErrTokenRevoked = errors.New("token has been revoked")
+
+// AccessClaims is the subset of access token claims the API relies on.
+type AccessClaims struct {
+	UserID    string
+	TokenID   string
+	FamilyID  string
+	ExpiresAt time.Time
+	// Scopes is nil for unrestricted sessions.
+	Scopes []string
+	// MFAAt is when the session last presented a second factor, if ever.
+	MFAAt *time.Time
+}
+
+type TokenPair struct {
+	AccessToken      string
+	AccessExpiresAt  time.Time
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+}
+
+// issueSessionAccessToken issues an access token reflecting the current state
+// of the session and its user.
+func issueSessionAccessToken(tx *gorm.DB, userID, sessionID uuid.UUID) (string, time.Time, error) {
+	scopes, err := sessionScopes(tx, userID)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	var session models.Session
+	if err := tx.Select("mfa_verified_at").Where("id = ?", sessionID).First(&session).Error; err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to load session: %v", err)
+	}
+
+	return issueAccessToken(userID, sessionID, scopes, session.MFAVerifiedAt)
+}
+
+func issueAccessToken(userID, familyID uuid.UUID, scopes []string, mfaAt *time.Time) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(appConfig.AppConfig.AccessTokenTTL)
+
+	claims := jwt.MapClaims{
+		"iss":    appConfig.AppConfig.BackendURL,
+		"sub":    userID.String(),
+		"typ":    "access",
+		"userID": userID.String(),
+		"jti":    uuid.New().String(),
+		"fid":    familyID.String(),
+		"iat":    now.Unix(),
+		"exp":    expiresAt.Unix(),
+	}
+	if scopes != nil {
+		claims["scp"] = strings.Join(scopes, " ")
+	}
+	if mfaAt != nil {
+		claims["mfa"] = mfaAt.Unix()
+	}
+
+	tokenString, err := signToken(claims)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to sign access token: %v", err)
+	}
+
+	return tokenString, expiresAt, nil
+}
+
+// ParseAccessToken verifies an access token and rejects it if the token itself
+// or its refresh token family has been revoked.
+func ParseAccessToken(tokenString string) (*AccessClaims, error) {
+	mapClaims, err := parseToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	if typ, _ := mapClaims["typ"].(string); typ != "access" {
+		return nil, ErrInvalidToken
+	}
+
+	claims := &AccessClaims{}
+	claims.UserID, _ = mapClaims["userID"].(string)
+	claims.TokenID, _ = mapClaims["jti"].(string)
+	claims.FamilyID, _ = mapClaims["fid"].(string)
+	if scp, ok := mapClaims["scp"].(string); ok {
+		claims.Scopes = strings.Fields(scp)
+	}
+	if mfa, ok := mapClaims["mfa"].(float64); ok {
+		mfaAt := time.Unix(int64(mfa), 0)
+		claims.MFAAt = &mfaAt
+	}
+	if exp, ok := mapClaims["exp"].(float64); ok {
+		claims.ExpiresAt = time.Unix(int64(exp), 0)
+	}
+
+	if claims.UserID == "" || claims.TokenID == "" || claims.FamilyID == "" {
+		return nil, ErrInvalidToken
+	}
+
+	revoked, err := isRevoked(claims)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, ErrTokenRevoked
+	}
+
+	return claims, nil
+}
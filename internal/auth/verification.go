@@ -0,0 +1,173 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	appConfig "github.com/souvik150/file-sharing-app/internal/config"
+	"github.com/souvik150/file-sharing-app/internal/database"
+	"github.com/souvik150/file-sharing-app/internal/models"
+	"github.com/souvik150/file-sharing-app/pkg/mailer"
+)
+
+var ErrVerificationTokenInvalid = errors.New("verification token is invalid or expired")
+var ErrResetTokenInvalid = errors.New("password reset token is invalid, expired or already used")
+var ErrEmailNotVerified = errors.New("email address has not been verified")
+
+// SendEmailVerification issues a fresh verification token for the user,
+// replacing any previous one, and mails it.
+func SendEmailVerification(user *models.User) error {
+	rawToken, err := generateOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	expiresAt := time.Now().Add(appConfig.AppConfig.EmailVerificationTTL)
+	err = database.GetDB().Model(user).Updates(map[string]interface{}{
+		"verification_token_hash": hashToken(rawToken),
+		"verification_expires_at": expiresAt,
+	}).Error
+	if err != nil {
+		return fmt.Errorf("failed to store verification token: %v", err)
+	}
+
+	link := fmt.Sprintf("%s/verify-email?token=%s", appConfig.AppConfig.BackendURL, rawToken)
+	return mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Welcome!\n\nConfirm your email address by opening the link below:\n\n%s\n\nThe link expires on %s.\n",
+			link, expiresAt.Format(time.RFC1123)),
+	})
+}
+
+func VerifyEmail(rawToken string) (*models.User, error) {
+	db := database.GetDB()
+
+	var user models.User
+	if err := db.Where("verification_token_hash = ?", hashToken(rawToken)).First(&user).Error; err != nil {
+		return nil, ErrVerificationTokenInvalid
+	}
+
+	if user.VerificationExpiresAt == nil || time.Now().After(*user.VerificationExpiresAt) {
+		return nil, ErrVerificationTokenInvalid
+	}
+
+	err := db.Model(&user).Updates(map[string]interface{}{
+		"email_verified_at":       time.Now(),
+		"verification_token_hash": "",
+		"verification_expires_at": nil,
+	}).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark email as verified: %v", err)
+	}
+
+	return &user, nil
+}
+
+// SendPasswordReset mails a single-use reset token to the user.
+func SendPasswordReset(user *models.User) error {
+	rawToken, err := generateOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	resetToken := models.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: hashToken(rawToken),
+		ExpiresAt: time.Now().Add(appConfig.AppConfig.PasswordResetTTL),
+	}
+	if err := database.GetDB().Create(&resetToken).Error; err != nil {
+		return fmt.Errorf("failed to store password reset token: %v", err)
+	}
+
+	return mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Someone asked to reset the password for this account.\n\n"+
+			"Send the token below with your new password to POST %s/password-reset/confirm:\n\n%s\n\n"+
+			"The token can be used once and expires on %s. If you did not ask for this, you can ignore this email.\n",
+			appConfig.AppConfig.BackendURL, rawToken, resetToken.ExpiresAt.Format(time.RFC1123)),
+	})
+}
+
+// ResetPassword consumes a reset token, sets the new password and revokes
+// every session of the user.
+func ResetPassword(rawToken, newPassword string) error {
+	var resetToken models.PasswordResetToken
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Preload("User").Where("token_hash = ?", hashToken(rawToken)).First(&resetToken).Error; err != nil {
+			return ErrResetTokenInvalid
+		}
+
+		if time.Now().After(resetToken.ExpiresAt) {
+			return ErrResetTokenInvalid
+		}
+
+		// Check the policy before burning the token so the user can retry
+		// with a stronger password.
+		if err := ValidatePassword(newPassword, resetToken.User.Email); err != nil {
+			return err
+		}
+
+		hashedPassword, err := HashPassword(newPassword)
+		if err != nil {
+			return err
+		}
+
+		result := tx.Model(&models.PasswordResetToken{}).
+			Where("id = ? AND used_at IS NULL", resetToken.ID).
+			Update("used_at", time.Now())
+		if result.Error != nil {
+			return fmt.Errorf("failed to consume password reset token: %v", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrResetTokenInvalid
+		}
+
+		// Receiving the reset email proves ownership of the address.
+		return tx.Model(&models.User{}).Where("id = ?", resetToken.UserID).Updates(map[string]interface{}{
+			"password":          hashedPassword,
+			"email_verified_at": gorm.Expr("COALESCE(email_verified_at, ?)", time.Now()),
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = RevokeUserSessions(resetToken.UserID, uuid.Nil)
+	return err
+}
+
+// CheckLoginAllowed rejects disabled accounts and applies
+// UNVERIFIED_LOGIN_POLICY to a user who has just proven their credentials.
+func CheckLoginAllowed(user *models.User) error {
+	if user.DisabledAt != nil {
+		return ErrAccountDisabled
+	}
+	if user.EmailVerifiedAt == nil && appConfig.AppConfig.UnverifiedLoginPolicy == "block" {
+		return ErrEmailNotVerified
+	}
+	return nil
+}
+
+// sessionScopes returns the scopes to embed in a user's access tokens, or nil
+// for unrestricted access.
+func sessionScopes(tx *gorm.DB, userID uuid.UUID) ([]string, error) {
+	if appConfig.AppConfig.UnverifiedLoginPolicy != "limited" {
+		return nil, nil
+	}
+
+	var user models.User
+	if err := tx.Select("email_verified_at").Where("id = ?", userID).First(&user).Error; err != nil {
+		return nil, fmt.Errorf("failed to load user: %v", err)
+	}
+
+	if user.EmailVerifiedAt == nil {
+		return []string{ScopeFilesRead}, nil
+	}
+	return nil, nil
+}
@@ -2,6 +2,8 @@ package config
 
 import (
 	"log"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/spf13/viper"
@@ -16,6 +18,50 @@ type Config struct {
 		EncryptionKey string
 		BucketName string
 		BackendURL string
+		AccessTokenTTL time.Duration
+		RefreshTokenTTL time.Duration
+		JWTSigningKeysDir string
+		JWTAllowEphemeralKey bool
+		JWTActiveKeyID string
+		MailDriver string
+		MailFrom string
+		MailOutboxDir string
+		SMTPHost string
+		SMTPPort int
+		SMTPUsername string
+		SMTPPassword string
+		UnverifiedLoginPolicy string
+		EmailVerificationTTL time.Duration
+		PasswordResetTTL time.Duration
+		TOTPIssuer string
+		MFAChallengeTTL time.Duration
+		MFARecentWindow time.Duration
+		OIDCIssuerURL string
+		OIDCClientID string
+		OIDCClientSecret string
+		OIDCRedirectURL string
+		LoginMaxAttemptsPerEmail int64
+		LoginMaxAttemptsPerIP int64
+		LoginAttemptWindow time.Duration
+		LoginLockoutDuration time.Duration
+		AdminEmails []string
+		PasswordMinLength int
+		PasswordMinEntropy float64
+		BreachedPasswordsFile string
+		AccountDeletionGracePeriod time.Duration
+		ExportDir string
+		ExportTTL time.Duration
+		SearchIndexMaxFileSize int64
+		TrashRetention time.Duration
+		VersionRetentionCount int
+		ScrubInterval time.Duration
+		UploadMaxFileSize int64
+		UploadAllowedTypes []string
+		UploadBlockedTypes []string
+		UploadRejectTypeMismatch bool
+		UploadWorkers int
+		StorageQuota int64
+		ThumbnailMaxFileSize int64
 }
 
 var AppConfig *Config
@@ -28,6 +74,38 @@ func LoadConfig() {
 
     viper.AutomaticEnv()
 
+    viper.SetDefault("ACCESS_TOKEN_TTL", "15m")
+    viper.SetDefault("REFRESH_TOKEN_TTL", "168h")
+    viper.SetDefault("MAIL_DRIVER", "outbox")
+    viper.SetDefault("MAIL_FROM", "no-reply@localhost")
+    viper.SetDefault("MAIL_OUTBOX_DIR", "outbox")
+    viper.SetDefault("SMTP_PORT", 587)
+    viper.SetDefault("UNVERIFIED_LOGIN_POLICY", "allow")
+    viper.SetDefault("EMAIL_VERIFICATION_TTL", "48h")
+    viper.SetDefault("PASSWORD_RESET_TTL", "1h")
+    viper.SetDefault("TOTP_ISSUER", "File Sharing App")
+    viper.SetDefault("MFA_CHALLENGE_TTL", "5m")
+    viper.SetDefault("MFA_RECENT_WINDOW", "10m")
+    viper.SetDefault("LOGIN_MAX_ATTEMPTS_PER_EMAIL", 5)
+    viper.SetDefault("LOGIN_MAX_ATTEMPTS_PER_IP", 20)
+    viper.SetDefault("LOGIN_ATTEMPT_WINDOW", "15m")
+    viper.SetDefault("LOGIN_LOCKOUT_DURATION", "15m")
+    viper.SetDefault("PASSWORD_MIN_LENGTH", 10)
+    viper.SetDefault("PASSWORD_MIN_ENTROPY", 45)
+    viper.SetDefault("ACCOUNT_DELETION_GRACE_PERIOD", "168h")
+    viper.SetDefault("EXPORT_DIR", "/local/exports")
+    viper.SetDefault("EXPORT_TTL", "24h")
+    viper.SetDefault("SEARCH_INDEX_MAX_FILE_SIZE", 20*1024*1024)
+    viper.SetDefault("TRASH_RETENTION", "720h")
+    viper.SetDefault("VERSION_RETENTION_COUNT", 10)
+    viper.SetDefault("SCRUB_INTERVAL", "720h")
+    viper.SetDefault("UPLOAD_MAX_FILE_SIZE", 1073741824)
+    viper.SetDefault("UPLOAD_BLOCKED_TYPES", "application/vnd.microsoft.portable-executable,application/x-elf,application/x-executable,application/x-sharedlib,application/x-mach-binary")
+    viper.SetDefault("UPLOAD_REJECT_TYPE_MISMATCH", false)
+    viper.SetDefault("UPLOAD_WORKERS", 4)
+    viper.SetDefault("STORAGE_QUOTA", 10737418240)
+    viper.SetDefault("THUMBNAIL_MAX_FILE_SIZE", 50*1024*1024)
+
     postgresURI := viper.GetString("POSTGRES_URI")
     if postgresURI == "" {
         log.Fatal("POSTGRES_URI is required")
@@ -68,6 +146,53 @@ func LoadConfig() {
 			log.Fatal("BACKEND_URL is required")
 		}
 
+		accessTokenTTL := viper.GetDuration("ACCESS_TOKEN_TTL")
+		if accessTokenTTL <= 0 {
+			log.Fatal("ACCESS_TOKEN_TTL must be a positive duration")
+		}
+
+		refreshTokenTTL := viper.GetDuration("REFRESH_TOKEN_TTL")
+		if refreshTokenTTL <= accessTokenTTL {
+			log.Fatal("REFRESH_TOKEN_TTL must be longer than ACCESS_TOKEN_TTL")
+		}
+
+		jwtSigningKeysDir := viper.GetString("JWT_SIGNING_KEYS_DIR")
+		jwtActiveKeyID := viper.GetString("JWT_ACTIVE_KEY_ID")
+		if jwtSigningKeysDir != "" && jwtActiveKeyID == "" {
+			log.Fatal("JWT_ACTIVE_KEY_ID is required when JWT_SIGNING_KEYS_DIR is set")
+		}
+		// A generated key changes on every restart and differs between
+		// instances, so it is only allowed when asked for explicitly.
+		jwtAllowEphemeralKey := viper.GetBool("JWT_ALLOW_EPHEMERAL_KEY")
+		if jwtSigningKeysDir == "" && !jwtAllowEphemeralKey {
+			log.Fatal("JWT_SIGNING_KEYS_DIR is required; set JWT_ALLOW_EPHEMERAL_KEY=true to generate a throwaway key for development")
+		}
+
+		mailDriver := viper.GetString("MAIL_DRIVER")
+		smtpHost := viper.GetString("SMTP_HOST")
+		if mailDriver == "smtp" && smtpHost == "" {
+			log.Fatal("SMTP_HOST is required when MAIL_DRIVER is smtp")
+		}
+
+		// allow: unverified users log in normally, limited: they get read-only
+		// access until they verify, block: they cannot log in at all.
+		unverifiedLoginPolicy := viper.GetString("UNVERIFIED_LOGIN_POLICY")
+		if unverifiedLoginPolicy != "allow" && unverifiedLoginPolicy != "limited" && unverifiedLoginPolicy != "block" {
+			log.Fatal("UNVERIFIED_LOGIN_POLICY must be one of allow, limited or block")
+		}
+
+		// Single sign-on is optional and only enabled when an issuer is set.
+		oidcIssuerURL := viper.GetString("OIDC_ISSUER_URL")
+		oidcClientID := viper.GetString("OIDC_CLIENT_ID")
+		if oidcIssuerURL != "" && oidcClientID == "" {
+			log.Fatal("OIDC_CLIENT_ID is required when OIDC_ISSUER_URL is set")
+		}
+
+		oidcRedirectURL := viper.GetString("OIDC_REDIRECT_URL")
+		if oidcRedirectURL == "" {
+			oidcRedirectURL = backendURL + "/auth/oidc/callback"
+		}
+
     AppConfig = &Config{
         PostgresURI: postgresURI,
         RedisURI:    redisURI,
@@ -77,5 +202,49 @@ func LoadConfig() {
 				EncryptionKey: encryptionKey,
 				BucketName: bucketName,
 				BackendURL: backendURL,
+				AccessTokenTTL: accessTokenTTL,
+				RefreshTokenTTL: refreshTokenTTL,
+				JWTSigningKeysDir: jwtSigningKeysDir,
+				JWTAllowEphemeralKey: jwtAllowEphemeralKey,
+				JWTActiveKeyID: jwtActiveKeyID,
+				MailDriver: mailDriver,
+				MailFrom: viper.GetString("MAIL_FROM"),
+				MailOutboxDir: viper.GetString("MAIL_OUTBOX_DIR"),
+				SMTPHost: smtpHost,
+				SMTPPort: viper.GetInt("SMTP_PORT"),
+				SMTPUsername: viper.GetString("SMTP_USERNAME"),
+				SMTPPassword: viper.GetString("SMTP_PASSWORD"),
+				UnverifiedLoginPolicy: unverifiedLoginPolicy,
+				EmailVerificationTTL: viper.GetDuration("EMAIL_VERIFICATION_TTL"),
+				PasswordResetTTL: viper.GetDuration("PASSWORD_RESET_TTL"),
+				TOTPIssuer: viper.GetString("TOTP_ISSUER"),
+				MFAChallengeTTL: viper.GetDuration("MFA_CHALLENGE_TTL"),
+				MFARecentWindow: viper.GetDuration("MFA_RECENT_WINDOW"),
+				OIDCIssuerURL: oidcIssuerURL,
+				OIDCClientID: oidcClientID,
+				OIDCClientSecret: viper.GetString("OIDC_CLIENT_SECRET"),
+				OIDCRedirectURL: oidcRedirectURL,
+				LoginMaxAttemptsPerEmail: viper.GetInt64("LOGIN_MAX_ATTEMPTS_PER_EMAIL"),
+				LoginMaxAttemptsPerIP: viper.GetInt64("LOGIN_MAX_ATTEMPTS_PER_IP"),
+				LoginAttemptWindow: viper.GetDuration("LOGIN_ATTEMPT_WINDOW"),
+				LoginLockoutDuration: viper.GetDuration("LOGIN_LOCKOUT_DURATION"),
+				PasswordMinLength: viper.GetInt("PASSWORD_MIN_LENGTH"),
+				PasswordMinEntropy: viper.GetFloat64("PASSWORD_MIN_ENTROPY"),
+				BreachedPasswordsFile: viper.GetString("BREACHED_PASSWORDS_FILE"),
+				AccountDeletionGracePeriod: viper.GetDuration("ACCOUNT_DELETION_GRACE_PERIOD"),
+				ExportDir: viper.GetString("EXPORT_DIR"),
+				ExportTTL: viper.GetDuration("EXPORT_TTL"),
+				SearchIndexMaxFileSize: viper.GetInt64("SEARCH_INDEX_MAX_FILE_SIZE"),
+				TrashRetention: viper.GetDuration("TRASH_RETENTION"),
+				VersionRetentionCount: viper.GetInt("VERSION_RETENTION_COUNT"),
+				ScrubInterval: viper.GetDuration("SCRUB_INTERVAL"),
+				UploadMaxFileSize: viper.GetInt64("UPLOAD_MAX_FILE_SIZE"),
+				UploadAllowedTypes: strings.Fields(strings.ToLower(strings.ReplaceAll(viper.GetString("UPLOAD_ALLOWED_TYPES"), ",", " "))),
+				UploadBlockedTypes: strings.Fields(strings.ToLower(strings.ReplaceAll(viper.GetString("UPLOAD_BLOCKED_TYPES"), ",", " "))),
+				UploadRejectTypeMismatch: viper.GetBool("UPLOAD_REJECT_TYPE_MISMATCH"),
+				UploadWorkers: viper.GetInt("UPLOAD_WORKERS"),
+				StorageQuota: viper.GetInt64("STORAGE_QUOTA"),
+				ThumbnailMaxFileSize: viper.GetInt64("THUMBNAIL_MAX_FILE_SIZE"),
+				AdminEmails: strings.Fields(strings.ToLower(strings.ReplaceAll(viper.GetString("ADMIN_EMAILS"), ",", " "))),
     }
 }
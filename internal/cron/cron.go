@@ -4,8 +4,12 @@ import (
 	"log"
 	"time"
 
+	"github.com/souvik150/file-sharing-app/internal/account"
 	"github.com/souvik150/file-sharing-app/internal/database"
+	"github.com/souvik150/file-sharing-app/internal/export"
+	"github.com/souvik150/file-sharing-app/internal/files"
 	"github.com/souvik150/file-sharing-app/internal/models"
+	"github.com/souvik150/file-sharing-app/internal/search"
 )
 func CleanUpExpiredLinks() {
 	ticker := time.NewTicker(15 * time.Minute)
@@ -16,4 +20,93 @@ func CleanUpExpiredLinks() {
 			dbClient.Where("expires_at <= ?", time.Now()).Delete(&models.SharedLink{})
 		}
 	}()
-}
\ No newline at end of file
+}
+func PurgeDeletedAccounts() {
+	ticker := time.NewTicker(time.Hour)
+	log.Println("Starting account deletion worker")
+	go func() {
+		for range ticker.C {
+			account.PurgeDueAccounts()
+		}
+	}()
+}
+
+func CleanUpExpiredExports() {
+	ticker := time.NewTicker(15 * time.Minute)
+	log.Println("Starting export cleanup worker")
+	go func() {
+		for range ticker.C {
+			export.CleanUpExpired()
+		}
+	}()
+}
+
+func PurgeExpiredTrash() {
+	ticker := time.NewTicker(time.Hour)
+	log.Println("Starting trash purge worker")
+	go func() {
+		for range ticker.C {
+			files.PurgeExpiredTrash()
+		}
+	}()
+}
+
+func EnforceVersionRetention() {
+	ticker := time.NewTicker(time.Hour)
+	log.Println("Starting version retention worker")
+	go func() {
+		for range ticker.C {
+			files.EnforceVersionRetention()
+		}
+	}()
+}
+
+func CollectBlobs() {
+	ticker := time.NewTicker(time.Hour)
+	log.Println("Starting blob collection worker")
+	go func() {
+		for range ticker.C {
+			files.CollectBlobs()
+		}
+	}()
+}
+
+func ScrubStoredFiles() {
+	ticker := time.NewTicker(10 * time.Minute)
+	log.Println("Starting integrity scrub worker")
+	go func() {
+		for range ticker.C {
+			files.Scrub()
+		}
+	}()
+}
+
+func ReconcileStorageUsage() {
+	ticker := time.NewTicker(24 * time.Hour)
+	log.Println("Starting storage usage reconciliation worker")
+	go func() {
+		for range ticker.C {
+			files.ReconcileStorageUsage()
+		}
+	}()
+}
+
+func GenerateThumbnails() {
+	ticker := time.NewTicker(5 * time.Minute)
+	log.Println("Starting thumbnail worker")
+	go func() {
+		for range ticker.C {
+			files.GeneratePendingThumbnails()
+		}
+	}()
+}
+
+func IndexPendingFiles() {
+	ticker := time.NewTicker(5 * time.Minute)
+	log.Println("Starting search indexing worker")
+	go func() {
+		for range ticker.C {
+			search.IndexPending()
+		}
+	}()
+}
@@ -9,14 +9,122 @@ import (
 )
 
 func Migrate(db *gorm.DB) {
+	dropNonCascadingFileOwnerKeys(db)
+	clearZeroFileDeletionTimes(db)
+	countUsage := db.Migrator().HasTable(&models.User{}) && !db.Migrator().HasColumn(&models.User{}, "StorageUsed")
+
 	err := db.AutoMigrate(
 		&models.User{},
+		&models.Folder{},
 		&models.File{},
+		&models.FileTag{},
+		&models.FileMetadata{},
+		&models.FileContent{},
+		&models.FilePreview{},
+		&models.Blob{},
+		&models.FileVersion{},
 		&models.SharedLink{},
+		&models.Session{},
+		&models.RefreshToken{},
+		&models.APIKey{},
+		&models.PasswordResetToken{},
+		&models.RecoveryCode{},
+		&models.UserIdentity{},
+		&models.AuditLog{},
+		&models.DeletionReceipt{},
+		&models.DataExport{},
 	)
 	if err != nil {
 		log.Fatalf("Failed to migrate database: %v", err)
 	}
 
+	createIndexes(db)
+	backfillFileVersions(db)
+	if countUsage {
+		backfillStorageUsage(db)
+	}
+
 	log.Println("Database migration completed successfully.")
-}
\ No newline at end of file
+}
+
+// createIndexes adds the indexes GORM tags cannot express.
+func createIndexes(db *gorm.DB) {
+	statements := []string{
+		// Root folders have a NULL parent, which a plain unique index would
+		// treat as distinct, so map it to the nil UUID.
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_folders_unique_name ON folders
+			(owner_id, COALESCE(parent_id, '00000000-0000-0000-0000-000000000000'), LOWER(name))
+			WHERE deleted_at IS NULL`,
+	}
+
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			log.Fatalf("Failed to create index: %v", err)
+		}
+	}
+}
+
+// backfillFileVersions gives files uploaded before versioning their first
+// version, stored under the file ID.
+func backfillFileVersions(db *gorm.DB) {
+	result := db.Exec(`INSERT INTO file_versions (id, file_id, version, object_key, file_name, file_type, size, created_at)
+		SELECT uuid_generate_v4(), files.id, 1, files.id::text, files.file_name, files.file_type, files.size, files.created_at
+		FROM files
+		WHERE NOT EXISTS (SELECT 1 FROM file_versions WHERE file_versions.file_id = files.id)`)
+	if result.Error != nil {
+		log.Fatalf("Failed to backfill file versions: %v", result.Error)
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("Created the first version of %d existing files", result.RowsAffected)
+	}
+}
+
+// backfillStorageUsage sets the usage of existing users when usage
+// accounting is introduced.
+func backfillStorageUsage(db *gorm.DB) {
+	err := db.Exec(`UPDATE users SET storage_used = COALESCE((
+		SELECT SUM(file_versions.size) FROM file_versions
+		JOIN files ON files.id = file_versions.file_id
+		WHERE files.owner_id = users.id), 0)`).Error
+	if err != nil {
+		log.Fatalf("Failed to backfill storage usage: %v", err)
+	}
+	log.Println("Computed the storage usage of existing users")
+}
+
+// clearZeroFileDeletionTimes converts the zero deletion time that used to
+// mark live files to NULL, which is what soft deletes expect, and flags files
+// deleted before then as deleted.
+func clearZeroFileDeletionTimes(db *gorm.DB) {
+	if !db.Migrator().HasTable("files") {
+		return
+	}
+
+	err := db.Exec("UPDATE files SET deleted_at = NULL WHERE deleted_at < '0002-01-01'").Error
+	if err == nil {
+		err = db.Exec("UPDATE files SET deleted_status = true WHERE deleted_at IS NOT NULL AND deleted_status = false").Error
+	}
+	if err != nil {
+		log.Fatalf("Failed to migrate file deletion times: %v", err)
+	}
+}
+
+// dropNonCascadingFileOwnerKeys removes the files owner foreign keys created
+// before they cascaded on delete. AutoMigrate then recreates them with the
+// constraint declared on the models.
+func dropNonCascadingFileOwnerKeys(db *gorm.DB) {
+	var names []string
+	err := db.Raw(`SELECT constraint_name FROM information_schema.referential_constraints
+		WHERE constraint_name IN ('fk_files_owner', 'fk_users_files') AND delete_rule <> 'CASCADE'`).
+		Scan(&names).Error
+	if err != nil {
+		log.Fatalf("Failed to inspect file owner constraints: %v", err)
+	}
+
+	for _, name := range names {
+		if err := db.Exec("ALTER TABLE files DROP CONSTRAINT " + name).Error; err != nil {
+			log.Fatalf("Failed to drop constraint %s: %v", name, err)
+		}
+		log.Printf("Dropped constraint %s so it can be recreated with ON DELETE CASCADE", name)
+	}
+}
@@ -0,0 +1,453 @@
+package export
+
+import (
+	"archive/zip"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	appConfig "github.com/souvik150/file-sharing-app/internal/config"
+	"github.com/souvik150/file-sharing-app/internal/database"
+	"github.com/souvik150/file-sharing-app/internal/models"
+	"github.com/souvik150/file-sharing-app/internal/socket"
+	"github.com/souvik150/file-sharing-app/pkg/s3"
+)
+
+var ErrExportInProgress = errors.New("an export is already in progress")
+var ErrExportNotReady = errors.New("export is not ready for download")
+var ErrDownloadTokenInvalid = errors.New("download link is invalid or expired")
+
+type manifest struct {
+	GeneratedAt string             `json:"generated_at"`
+	Account     manifestAccount    `json:"account"`
+	Files       []manifestFile     `json:"files"`
+	SharedLinks []manifestLink     `json:"shared_links"`
+	Sessions    []manifestSession  `json:"sessions"`
+	APIKeys     []manifestAPIKey   `json:"api_keys"`
+	Identities  []manifestIdentity `json:"linked_identities"`
+}
+
+type manifestAccount struct {
+	ID               uuid.UUID `json:"id"`
+	Email            string    `json:"email"`
+	Role             string    `json:"role"`
+	EmailVerified    bool      `json:"email_verified"`
+	TwoFactorEnabled bool      `json:"two_factor_enabled"`
+	CreatedAt        string    `json:"created_at"`
+}
+
+type manifestFile struct {
+	ID        uuid.UUID `json:"id"`
+	FileName  string    `json:"file_name"`
+	Size      int64     `json:"size"`
+	FileType  string    `json:"file_type"`
+	CreatedAt string    `json:"created_at"`
+	UpdatedAt string    `json:"updated_at"`
+	// Path is the file's location inside the archive. It is empty when the
+	// content could not be retrieved, in which case Error says why. Error is
+	// also set when only part of the content could be read.
+	Path  string `json:"path,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+type manifestLink struct {
+	FileID    uuid.UUID `json:"file_id"`
+	FileName  string    `json:"file_name"`
+	ExpiresAt string    `json:"expires_at"`
+}
+
+type manifestSession struct {
+	IPAddress  string `json:"ip_address"`
+	UserAgent  string `json:"user_agent"`
+	CreatedAt  string `json:"created_at"`
+	LastUsedAt string `json:"last_used_at"`
+}
+
+type manifestAPIKey struct {
+	Name      string `json:"name"`
+	Prefix    string `json:"prefix"`
+	Scopes    string `json:"scopes"`
+	CreatedAt string `json:"created_at"`
+	Revoked   bool   `json:"revoked"`
+}
+
+type manifestIdentity struct {
+	Issuer string `json:"issuer"`
+	Email  string `json:"email"`
+}
+
+// Request enqueues an export for the user. Only one export may be pending or
+// processing at a time.
+func Request(userID uuid.UUID) (*models.DataExport, error) {
+	db := database.GetDB()
+
+	var inProgress int64
+	err := db.Model(&models.DataExport{}).
+		Where("user_id = ? AND status IN ?", userID, []string{models.ExportPending, models.ExportProcessing}).
+		Count(&inProgress).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to check exports: %v", err)
+	}
+	if inProgress > 0 {
+		return nil, ErrExportInProgress
+	}
+
+	dataExport := models.DataExport{ID: uuid.New(), UserID: userID, Status: models.ExportPending}
+	if err := db.Create(&dataExport).Error; err != nil {
+		return nil, fmt.Errorf("failed to create export: %v", err)
+	}
+
+	go run(dataExport.ID)
+
+	return &dataExport, nil
+}
+
+func run(exportID uuid.UUID) {
+	db := database.GetDB()
+
+	var dataExport models.DataExport
+	if err := db.Preload("User").Where("id = ?", exportID).First(&dataExport).Error; err != nil {
+		log.Printf("❌ Error loading export %s: %v", exportID, err)
+		return
+	}
+	db.Model(&dataExport).Update("status", models.ExportProcessing)
+
+	path, size, err := buildArchive(&dataExport.User, exportID)
+	if err != nil {
+		log.Printf("❌ Export %s failed: %v", exportID, err)
+		db.Model(&dataExport).Updates(map[string]interface{}{
+			"status": models.ExportFailed,
+			"error":  err.Error(),
+		})
+		socket.NotifyUser(dataExport.UserID.String(), notification("export_failed", exportID, "", time.Time{}))
+		return
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(appConfig.AppConfig.ExportTTL)
+	dataExport.Status = models.ExportReady
+	dataExport.FilePath = path
+	dataExport.Size = size
+	dataExport.CompletedAt = &now
+	dataExport.ExpiresAt = &expiresAt
+	err = db.Model(&dataExport).Select("status", "file_path", "size", "completed_at", "expires_at").Updates(&dataExport).Error
+	if err != nil {
+		log.Printf("❌ Error saving export %s: %v", exportID, err)
+		os.Remove(path)
+		return
+	}
+
+	link, expiresAt, err := IssueDownloadLink(&dataExport)
+	if err != nil {
+		log.Printf("❌ Error issuing download link for export %s: %v", exportID, err)
+		return
+	}
+
+	socket.NotifyUser(dataExport.UserID.String(), notification("export_ready", exportID, link, expiresAt))
+	log.Printf("📦 Export %s ready (%d bytes)", exportID, size)
+}
+
+func notification(event string, exportID uuid.UUID, link string, expiresAt time.Time) string {
+	message := map[string]interface{}{
+		"type":      event,
+		"export_id": exportID,
+	}
+	if link != "" {
+		message["download_url"] = link
+		message["expires_at"] = expiresAt.Format("2006-01-02T15:04:05Z")
+	}
+
+	data, _ := json.Marshal(message)
+	return string(data)
+}
+
+// buildArchive writes the zip under a temporary name and only moves it into
+// place once complete, so a crash never leaves a truncated archive behind.
+func buildArchive(user *models.User, exportID uuid.UUID) (string, int64, error) {
+	exportDir := appConfig.AppConfig.ExportDir
+	if err := os.MkdirAll(exportDir, 0700); err != nil {
+		return "", 0, fmt.Errorf("failed to create export directory: %v", err)
+	}
+
+	finalPath := filepath.Join(exportDir, exportID.String()+".zip")
+	tmpPath := finalPath + ".tmp"
+
+	out, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create archive: %v", err)
+	}
+	defer os.Remove(tmpPath)
+
+	archive := zip.NewWriter(out)
+	if err := writeArchive(archive, user); err != nil {
+		archive.Close()
+		out.Close()
+		return "", 0, err
+	}
+	if err := archive.Close(); err != nil {
+		out.Close()
+		return "", 0, fmt.Errorf("failed to finish archive: %v", err)
+	}
+	if err := out.Close(); err != nil {
+		return "", 0, fmt.Errorf("failed to finish archive: %v", err)
+	}
+
+	info, err := os.Stat(tmpPath)
+	if err != nil {
+		return "", 0, err
+	}
+	if err := os.Rename(tmpPath, finalPath); err != nil {
+		return "", 0, fmt.Errorf("failed to store archive: %v", err)
+	}
+
+	return finalPath, info.Size(), nil
+}
+
+func writeArchive(archive *zip.Writer, user *models.User) error {
+	db := database.GetDB()
+
+	doc := manifest{
+		GeneratedAt: time.Now().Format("2006-01-02T15:04:05Z"),
+		Account: manifestAccount{
+			ID:               user.ID,
+			Email:            user.Email,
+			Role:             user.Role,
+			EmailVerified:    user.EmailVerifiedAt != nil,
+			TwoFactorEnabled: user.TOTPEnabledAt != nil,
+			CreatedAt:        user.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		},
+		Files:       []manifestFile{},
+		SharedLinks: []manifestLink{},
+		Sessions:    []manifestSession{},
+		APIKeys:     []manifestAPIKey{},
+		Identities:  []manifestIdentity{},
+	}
+
+	var userFiles []models.File
+	if err := db.Where("owner_id = ?", user.ID).Order("created_at").Find(&userFiles).Error; err != nil {
+		return fmt.Errorf("failed to load files: %v", err)
+	}
+
+	usedNames := map[string]bool{}
+	for _, file := range userFiles {
+		entry := manifestFile{
+			ID:        file.ID,
+			FileName:  file.FileName,
+			Size:      file.Size,
+			FileType:  file.FileType,
+			CreatedAt: file.CreatedAt.Format("2006-01-02T15:04:05Z"),
+			UpdatedAt: file.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+		}
+
+		content, err := s3.OpenFile(file.StorageKey())
+		if err != nil {
+			entry.Error = "content could not be retrieved"
+			log.Printf("⚠️ Export for user %s skipped file %s: %v", user.ID, file.ID, err)
+			doc.Files = append(doc.Files, entry)
+			continue
+		}
+
+		entry.Path = archivePath(file, usedNames)
+		writer, err := archive.Create(entry.Path)
+		if err != nil {
+			content.Close()
+			return fmt.Errorf("failed to add %s to archive: %v", file.FileName, err)
+		}
+		_, err = io.Copy(writer, content)
+		content.Close()
+		// An entry cannot be taken back out of the zip, so damaged content
+		// stays in the archive truncated and the manifest says so.
+		if errors.Is(err, s3.ErrObjectUnreadable) {
+			entry.Error = "content is damaged and was only partly exported"
+			log.Printf("⚠️ Export for user %s truncated file %s: %v", user.ID, file.ID, err)
+		} else if err != nil {
+			return fmt.Errorf("failed to add %s to archive: %v", file.FileName, err)
+		}
+
+		doc.Files = append(doc.Files, entry)
+	}
+
+	var links []models.SharedLink
+	err := db.Where("file_id IN (?)", db.Model(&models.File{}).Select("id").Where("owner_id = ?", user.ID)).Find(&links).Error
+	if err != nil {
+		return fmt.Errorf("failed to load share links: %v", err)
+	}
+	for _, link := range links {
+		doc.SharedLinks = append(doc.SharedLinks, manifestLink{
+			FileID:    link.FileID,
+			FileName:  link.FileName,
+			ExpiresAt: link.ExpiresAt.Format("2006-01-02T15:04:05Z"),
+		})
+	}
+
+	var sessions []models.Session
+	if err := db.Where("user_id = ?", user.ID).Order("created_at").Find(&sessions).Error; err != nil {
+		return fmt.Errorf("failed to load sessions: %v", err)
+	}
+	for _, session := range sessions {
+		doc.Sessions = append(doc.Sessions, manifestSession{
+			IPAddress:  session.IPAddress,
+			UserAgent:  session.UserAgent,
+			CreatedAt:  session.CreatedAt.Format("2006-01-02T15:04:05Z"),
+			LastUsedAt: session.LastUsedAt.Format("2006-01-02T15:04:05Z"),
+		})
+	}
+
+	var apiKeys []models.APIKey
+	if err := db.Where("user_id = ?", user.ID).Order("created_at").Find(&apiKeys).Error; err != nil {
+		return fmt.Errorf("failed to load API keys: %v", err)
+	}
+	for _, apiKey := range apiKeys {
+		doc.APIKeys = append(doc.APIKeys, manifestAPIKey{
+			Name:      apiKey.Name,
+			Prefix:    apiKey.Prefix,
+			Scopes:    apiKey.Scopes,
+			CreatedAt: apiKey.CreatedAt.Format("2006-01-02T15:04:05Z"),
+			Revoked:   apiKey.RevokedAt != nil,
+		})
+	}
+
+	var identities []models.UserIdentity
+	if err := db.Where("user_id = ?", user.ID).Find(&identities).Error; err != nil {
+		return fmt.Errorf("failed to load linked identities: %v", err)
+	}
+	for _, identity := range identities {
+		doc.Identities = append(doc.Identities, manifestIdentity{Issuer: identity.Issuer, Email: identity.Email})
+	}
+
+	writer, err := archive.Create("manifest.json")
+	if err != nil {
+		return fmt.Errorf("failed to add manifest: %v", err)
+	}
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("failed to write manifest: %v", err)
+	}
+
+	return nil
+}
+
+// archivePath places a file under files/ using its original name, adding the
+// file ID when two files share a name.
+func archivePath(file models.File, usedNames map[string]bool) string {
+	name := filepath.Base(strings.ReplaceAll(file.FileName, "\\", "/"))
+	if name == "." || name == "/" || name == "" {
+		name = file.ID.String()
+	}
+
+	if usedNames[name] {
+		ext := filepath.Ext(name)
+		name = strings.TrimSuffix(name, ext) + "-" + file.ID.String() + ext
+	}
+	usedNames[name] = true
+
+	return "files/" + name
+}
+
+// IssueDownloadLink replaces the download token of a ready export and returns
+// a link that works until the export expires.
+func IssueDownloadLink(dataExport *models.DataExport) (string, time.Time, error) {
+	if dataExport.Status != models.ExportReady || dataExport.ExpiresAt == nil || time.Now().After(*dataExport.ExpiresAt) {
+		return "", time.Time{}, ErrExportNotReady
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to generate download token: %v", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+
+	if err := database.GetDB().Model(dataExport).Update("download_token_hash", hashToken(token)).Error; err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to store download token: %v", err)
+	}
+
+	link := fmt.Sprintf("%s/exports/%s/download?token=%s", appConfig.AppConfig.BackendURL, dataExport.ID, token)
+	return link, *dataExport.ExpiresAt, nil
+}
+
+// ResolveDownload returns the export a download link points at.
+func ResolveDownload(exportID, token string) (*models.DataExport, error) {
+	if token == "" {
+		return nil, ErrDownloadTokenInvalid
+	}
+
+	var dataExport models.DataExport
+	err := database.GetDB().
+		Where("id = ? AND download_token_hash = ? AND status = ?", exportID, hashToken(token), models.ExportReady).
+		First(&dataExport).Error
+	if err != nil {
+		return nil, ErrDownloadTokenInvalid
+	}
+
+	if dataExport.ExpiresAt == nil || time.Now().After(*dataExport.ExpiresAt) {
+		return nil, ErrDownloadTokenInvalid
+	}
+
+	return &dataExport, nil
+}
+
+// CleanUpExpired deletes archives past their expiry along with their records,
+// and fails exports that were interrupted by a restart.
+func CleanUpExpired() {
+	db := database.GetDB()
+
+	var expired []models.DataExport
+	if err := db.Where("expires_at <= ?", time.Now()).Find(&expired).Error; err != nil {
+		log.Printf("❌ Error finding expired exports: %v", err)
+		return
+	}
+	for i := range expired {
+		if err := Delete(&expired[i]); err != nil {
+			log.Printf("❌ Error deleting expired export %s: %v", expired[i].ID, err)
+		}
+	}
+
+	db.Model(&models.DataExport{}).
+		Where("status IN ? AND created_at <= ?", []string{models.ExportPending, models.ExportProcessing}, time.Now().Add(-appConfig.AppConfig.ExportTTL)).
+		Updates(map[string]interface{}{"status": models.ExportFailed, "error": "export was interrupted"})
+}
+
+// DeleteUserExports removes every export of a user, used when the account is
+// deleted.
+func DeleteUserExports(userID uuid.UUID) error {
+	var exports []models.DataExport
+	if err := database.GetDB().Where("user_id = ?", userID).Find(&exports).Error; err != nil {
+		return fmt.Errorf("failed to load exports: %v", err)
+	}
+	for i := range exports {
+		if err := Delete(&exports[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func Delete(dataExport *models.DataExport) error {
+	if dataExport.FilePath != "" {
+		if err := os.Remove(dataExport.FilePath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove archive: %v", err)
+		}
+	}
+	if err := database.GetDB().Delete(dataExport).Error; err != nil {
+		return fmt.Errorf("failed to delete export record: %v", err)
+	}
+	return nil
+}
+
+func hashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,235 @@
+package files
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/souvik150/file-sharing-app/internal/cache"
+	"github.com/souvik150/file-sharing-app/internal/database"
+	"github.com/souvik150/file-sharing-app/internal/models"
+	"github.com/souvik150/file-sharing-app/pkg/s3"
+)
+
+// collectBatchSize bounds how many blobs one run of CollectBlobs removes.
+const collectBatchSize = 500
+
+// Deduplicator shares stored content between one owner's uploads. Its Skip
+// method is handed to s3.ProcessFilesAsync, and Store then resolves each
+// processed upload to the blob holding its content.
+type Deduplicator struct {
+	ownerID  uuid.UUID
+	mu       sync.Mutex
+	reserved map[string]*models.Blob
+}
+
+func NewDeduplicator(ownerID uuid.UUID) *Deduplicator {
+	return &Deduplicator{ownerID: ownerID, reserved: make(map[string]*models.Blob)}
+}
+
+// Skip reports whether the owner already stores content with this digest.
+// It takes a reference on the existing blob right away so the blob cannot be
+// collected before Store hands it out.
+func (d *Deduplicator) Skip(key, sha256 string) bool {
+	blob, err := reserveBlob(database.GetDB(), d.ownerID, sha256)
+	if err != nil {
+		log.Printf("⚠️ Error looking up stored content for %s: %v", key, err)
+		return false
+	}
+	if blob == nil {
+		return false
+	}
+
+	d.mu.Lock()
+	d.reserved[key] = blob
+	d.mu.Unlock()
+	return true
+}
+
+// Store returns the blob holding a processed upload, creating one for freshly
+// uploaded content. The caller owns one reference on the blob and must link
+// it to a version or release it.
+func (d *Deduplicator) Store(upload s3.UploadedFile) (*models.Blob, error) {
+	if upload.Deduplicated {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		blob, ok := d.reserved[upload.Key]
+		if !ok {
+			return nil, fmt.Errorf("no stored content reserved for %s", upload.Key)
+		}
+		return blob, nil
+	}
+
+	db := database.GetDB()
+	blob := models.Blob{
+		ID:        uuid.New(),
+		OwnerID:   d.ownerID,
+		SHA256:    upload.SHA256,
+		ObjectKey: upload.Key,
+		Size:      upload.Size,
+		RefCount:  1,
+	}
+	err := db.Create(&blob).Error
+	if err == nil {
+		return &blob, nil
+	}
+	if !isUniqueViolation(err) {
+		return nil, fmt.Errorf("failed to record stored content: %v", err)
+	}
+
+	// An identical upload finished first. Share its blob and drop the
+	// duplicate object.
+	existing, err := reserveBlob(db, d.ownerID, upload.SHA256)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, fmt.Errorf("stored content for %s disappeared", upload.Key)
+	}
+	if err := s3.DeleteFileFromS3(upload.Key); err != nil {
+		log.Printf("⚠️ Error removing duplicate object %s: %v", upload.Key, err)
+	}
+	return existing, nil
+}
+
+// reserveBlob takes a reference on the owner's live blob with the digest and
+// returns it, or nil when there is none. Blobs already down to zero
+// references are left to the collector.
+func reserveBlob(db *gorm.DB, ownerID uuid.UUID, sha256 string) (*models.Blob, error) {
+	var blobs []models.Blob
+	err := db.Raw(`UPDATE blobs SET ref_count = ref_count + 1, updated_at = ?
+		WHERE owner_id = ? AND sha256 = ? AND ref_count > 0
+		RETURNING *`, time.Now(), ownerID, sha256).
+		Scan(&blobs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve stored content: %v", err)
+	}
+	if len(blobs) == 0 {
+		return nil, nil
+	}
+	return &blobs[0], nil
+}
+
+// retainBlob takes another reference on a blob known to be live.
+func retainBlob(db *gorm.DB, blobID uuid.UUID) error {
+	result := db.Exec("UPDATE blobs SET ref_count = ref_count + 1, updated_at = ? WHERE id = ? AND ref_count > 0", time.Now(), blobID)
+	if result.Error != nil {
+		return fmt.Errorf("failed to reference stored content: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("stored content %s is no longer available", blobID)
+	}
+	return nil
+}
+
+// ReleaseBlob drops a reference. Blobs left without references are removed
+// by CollectBlobs.
+func ReleaseBlob(db *gorm.DB, blobID uuid.UUID) error {
+	err := db.Exec("UPDATE blobs SET ref_count = ref_count - 1, updated_at = ? WHERE id = ?", time.Now(), blobID).Error
+	if err != nil {
+		return fmt.Errorf("failed to release stored content: %v", err)
+	}
+	return nil
+}
+
+// LinkVersionBlob records that a version's content lives in a blob once its
+// upload has been processed, moving the file onto the blob's object when the
+// version is current. The reference is released if the version was deleted
+// in the meantime.
+func LinkVersionBlob(fileID uuid.UUID, version int, blob *models.Blob) error {
+	linked := false
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.FileVersion{}).
+			Where("file_id = ? AND version = ?", fileID, version).
+			Updates(map[string]interface{}{
+				"blob_id":    blob.ID,
+				"sha256":     blob.SHA256,
+				"object_key": blob.ObjectKey,
+				"pending":    false,
+			})
+		if result.Error != nil {
+			return fmt.Errorf("failed to link version: %v", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ReleaseBlob(tx, blob.ID)
+		}
+		linked = true
+
+		err := tx.Unscoped().Model(&models.File{}).
+			Where("id = ? AND current_version = ?", fileID, version).
+			Updates(map[string]interface{}{
+				"object_key": blob.ObjectKey,
+				"sha256":     blob.SHA256,
+			}).Error
+		if err != nil {
+			return fmt.Errorf("failed to update file: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if linked {
+		cache.GetClient().Del(cache.Ctx, fileID.String())
+	}
+	return nil
+}
+
+// releaseVersionBlobs drops the blob references of versions being deleted.
+func releaseVersionBlobs(tx *gorm.DB, versions []models.FileVersion) error {
+	for _, version := range versions {
+		if version.BlobID == nil {
+			continue
+		}
+		if err := ReleaseBlob(tx, *version.BlobID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CollectBlobs deletes the objects of blobs nothing references any more, then
+// their rows.
+func CollectBlobs() {
+	collected, err := collectBlobs(database.GetDB().Limit(collectBatchSize))
+	if err != nil {
+		log.Printf("❌ Error collecting unreferenced blobs: %v", err)
+	}
+	if collected > 0 {
+		log.Printf("🗑️ Collected %d unreferenced blobs", collected)
+	}
+}
+
+// CollectOwnerBlobs deletes every unreferenced blob of an owner. Account
+// purges call it once the owner's files are gone, before the blob rows would
+// be removed along with the user and their objects lost track of.
+func CollectOwnerBlobs(ownerID uuid.UUID) error {
+	_, err := collectBlobs(database.GetDB().Where("owner_id = ?", ownerID))
+	return err
+}
+
+func collectBlobs(query *gorm.DB) (int, error) {
+	db := database.GetDB()
+
+	var unreferenced []models.Blob
+	if err := query.Where("ref_count <= 0").Find(&unreferenced).Error; err != nil {
+		return 0, fmt.Errorf("failed to load unreferenced blobs: %v", err)
+	}
+
+	collected := 0
+	for _, blob := range unreferenced {
+		if err := s3.DeleteFileFromS3(blob.ObjectKey); err != nil {
+			return collected, fmt.Errorf("failed to delete object of blob %s: %v", blob.ID, err)
+		}
+		if err := db.Where("id = ? AND ref_count <= 0", blob.ID).Delete(&models.Blob{}).Error; err != nil {
+			return collected, fmt.Errorf("failed to delete blob %s: %v", blob.ID, err)
+		}
+		collected++
+	}
+	return collected, nil
+}
@@ -0,0 +1,109 @@
+package files
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"path/filepath"
+	"strings"
+
+	"github.com/gabriel-vasile/mimetype"
+
+	"github.com/souvik150/file-sharing-app/internal/config"
+)
+
+var ErrFileTooLarge = errors.New("file exceeds the maximum upload size")
+var ErrFileTypeNotAllowed = errors.New("file type is not allowed")
+var ErrFileTypeMismatch = errors.New("file content does not match its extension")
+
+// DetectedType is what an upload turns out to be from its content.
+type DetectedType struct {
+	// MimeType is the sniffed type without parameters.
+	MimeType string
+	// Extension is the file name's extension without the dot, or the one
+	// matching the content when the name has none.
+	Extension string
+	// Mismatch is set when the extension claims a different type than the
+	// content has.
+	Mismatch bool
+
+	mime *mimetype.MIME
+}
+
+// DetectType sniffs the type of an upload from the start of its content.
+func DetectType(content io.Reader, fileName string) (*DetectedType, error) {
+	detected, err := mimetype.DetectReader(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect file type: %v", err)
+	}
+
+	mimeType, _, _ := strings.Cut(detected.String(), ";")
+	result := &DetectedType{MimeType: mimeType, mime: detected}
+
+	extension := strings.ToLower(strings.TrimPrefix(filepath.Ext(fileName), "."))
+	if extension == "" {
+		result.Extension = strings.TrimPrefix(detected.Extension(), ".")
+		return result, nil
+	}
+	result.Extension = extension
+	result.Mismatch = !matchesExtension(detected, extension)
+	return result, nil
+}
+
+// matchesExtension reports whether content of the detected type may carry
+// the extension. Extensions without a registered type never mismatch, and
+// plain text passes for any textual type since it has few reliable markers.
+func matchesExtension(detected *mimetype.MIME, extension string) bool {
+	expected, _, _ := strings.Cut(mime.TypeByExtension("."+extension), ";")
+	for m := detected; m != nil; m = m.Parent() {
+		if m.Extension() == "."+extension || (expected != "" && m.Is(expected)) {
+			return true
+		}
+	}
+	if expected == "" {
+		return true
+	}
+	return detected.Is("text/plain") && strings.HasPrefix(expected, "text/")
+}
+
+// CheckUploadPolicy applies the configured size limit and type lists to an
+// upload. Blocked types always lose; when an allow list is set, only the
+// types on it get through.
+func CheckUploadPolicy(size int64, detected *DetectedType) error {
+	if limit := config.AppConfig.UploadMaxFileSize; limit > 0 && size > limit {
+		return fmt.Errorf("%w of %d bytes", ErrFileTooLarge, limit)
+	}
+
+	for _, pattern := range config.AppConfig.UploadBlockedTypes {
+		if typeMatches(pattern, detected) {
+			return fmt.Errorf("%w: %s", ErrFileTypeNotAllowed, detected.MimeType)
+		}
+	}
+	if allowed := config.AppConfig.UploadAllowedTypes; len(allowed) > 0 {
+		permitted := false
+		for _, pattern := range allowed {
+			if typeMatches(pattern, detected) {
+				permitted = true
+				break
+			}
+		}
+		if !permitted {
+			return fmt.Errorf("%w: %s", ErrFileTypeNotAllowed, detected.MimeType)
+		}
+	}
+
+	if detected.Mismatch && config.AppConfig.UploadRejectTypeMismatch {
+		return fmt.Errorf("%w: .%s holds %s", ErrFileTypeMismatch, detected.Extension, detected.MimeType)
+	}
+	return nil
+}
+
+// typeMatches matches a MIME type, or a "type/*" pattern, against the
+// detected type and its aliases.
+func typeMatches(pattern string, detected *DetectedType) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "/*"); ok {
+		return strings.HasPrefix(detected.MimeType, prefix+"/")
+	}
+	return detected.mime.Is(pattern)
+}
@@ -0,0 +1,253 @@
+package files
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+
+	"github.com/souvik150/file-sharing-app/internal/cache"
+	"github.com/souvik150/file-sharing-app/internal/database"
+	"github.com/souvik150/file-sharing-app/internal/models"
+)
+
+const maxFolderNameLength = 255
+
+var ErrFolderNotFound = errors.New("folder not found")
+var ErrFolderNameTaken = errors.New("a folder with this name already exists here")
+var ErrInvalidFolderName = errors.New("folder name must be 1-255 characters and cannot contain slashes")
+var ErrFolderCycle = errors.New("a folder cannot be moved into itself or one of its subfolders")
+
+func normalizeFolderName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." || name == ".." || len(name) > maxFolderNameLength || strings.ContainsAny(name, "/\\") {
+		return "", ErrInvalidFolderName
+	}
+	return name, nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+// GetFolder loads a live folder belonging to the owner.
+func GetFolder(ownerID, folderID uuid.UUID) (*models.Folder, error) {
+	return getFolder(database.GetDB(), ownerID, folderID)
+}
+
+func getFolder(tx *gorm.DB, ownerID, folderID uuid.UUID) (*models.Folder, error) {
+	var folder models.Folder
+	if err := tx.Where("id = ? AND owner_id = ?", folderID, ownerID).First(&folder).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrFolderNotFound
+		}
+		return nil, fmt.Errorf("failed to load folder: %v", err)
+	}
+	return &folder, nil
+}
+
+// checkParent verifies that a destination folder exists for the owner. A nil
+// parent is the root and always exists.
+func checkParent(tx *gorm.DB, ownerID uuid.UUID, parentID *uuid.UUID) error {
+	if parentID == nil {
+		return nil
+	}
+	_, err := getFolder(tx, ownerID, *parentID)
+	return err
+}
+
+func CreateFolder(ownerID uuid.UUID, parentID *uuid.UUID, name string) (*models.Folder, error) {
+	name, err := normalizeFolderName(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkParent(database.GetDB(), ownerID, parentID); err != nil {
+		return nil, err
+	}
+
+	folder := models.Folder{ID: uuid.New(), OwnerID: ownerID, ParentID: parentID, Name: name}
+	if err := database.GetDB().Create(&folder).Error; err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrFolderNameTaken
+		}
+		return nil, fmt.Errorf("failed to create folder: %v", err)
+	}
+
+	return &folder, nil
+}
+
+func RenameFolder(folder *models.Folder, name string) error {
+	name, err := normalizeFolderName(name)
+	if err != nil {
+		return err
+	}
+
+	if err := database.GetDB().Model(folder).Update("name", name).Error; err != nil {
+		if isUniqueViolation(err) {
+			return ErrFolderNameTaken
+		}
+		return fmt.Errorf("failed to rename folder: %v", err)
+	}
+	folder.Name = name
+	return nil
+}
+
+// MoveFolder re-parents a folder, refusing moves that would make it its own
+// ancestor. Moves by the same owner are serialized, so two concurrent moves
+// cannot each pass the check and together form a cycle.
+func MoveFolder(folder *models.Folder, parentID *uuid.UUID) error {
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "folders:"+folder.OwnerID.String()).Error; err != nil {
+			return fmt.Errorf("failed to lock folders: %v", err)
+		}
+		if err := checkParent(tx, folder.OwnerID, parentID); err != nil {
+			return err
+		}
+
+		if parentID != nil {
+			ancestors, err := folderPath(tx, folder.OwnerID, *parentID)
+			if err != nil {
+				return err
+			}
+			for _, ancestor := range ancestors {
+				if ancestor.ID == folder.ID {
+					return ErrFolderCycle
+				}
+			}
+		}
+
+		if err := tx.Model(folder).Update("parent_id", parentID).Error; err != nil {
+			if isUniqueViolation(err) {
+				return ErrFolderNameTaken
+			}
+			return fmt.Errorf("failed to move folder: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	folder.ParentID = parentID
+	return nil
+}
+
+// FolderPath returns the breadcrumb trail from the root down to the folder,
+// the folder itself included.
+func FolderPath(ownerID, folderID uuid.UUID) ([]models.Folder, error) {
+	return folderPath(database.GetDB(), ownerID, folderID)
+}
+
+// folderPath walks up the parents, stopping at any folder already visited so
+// a corrupt hierarchy cannot make the query loop forever.
+func folderPath(tx *gorm.DB, ownerID, folderID uuid.UUID) ([]models.Folder, error) {
+	var path []models.Folder
+	err := tx.Raw(`
+		WITH RECURSIVE path AS (
+			SELECT id, owner_id, parent_id, name, 0 AS depth, ARRAY[id] AS visited
+			FROM folders WHERE id = ? AND owner_id = ? AND deleted_at IS NULL
+			UNION ALL
+			SELECT f.id, f.owner_id, f.parent_id, f.name, p.depth + 1, p.visited || f.id
+			FROM folders f JOIN path p ON f.id = p.parent_id
+			WHERE f.deleted_at IS NULL AND NOT f.id = ANY(p.visited)
+		)
+		SELECT id, owner_id, parent_id, name FROM path ORDER BY depth DESC`, folderID, ownerID).
+		Scan(&path).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve folder path: %v", err)
+	}
+	if len(path) == 0 {
+		return nil, ErrFolderNotFound
+	}
+	return path, nil
+}
+
+// subtreeIDs returns the folder and every live folder below it. UNION drops
+// folders already collected, which ends the recursion even on a cycle.
+func subtreeIDs(tx *gorm.DB, folderID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := tx.Raw(`
+		WITH RECURSIVE tree AS (
+			SELECT id FROM folders WHERE id = ?
+			UNION
+			SELECT f.id FROM folders f JOIN tree t ON f.parent_id = t.id
+			WHERE f.deleted_at IS NULL
+		)
+		SELECT id FROM tree`, folderID).
+		Scan(&ids).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect subfolders: %v", err)
+	}
+	return ids, nil
+}
+
+// DeleteFolder soft-deletes the folder, everything below it and the files
+// they contain, all with the same deletion time. It returns how many folders
+// and files were deleted.
+func DeleteFolder(folder *models.Folder) (int64, int64, error) {
+	var foldersDeleted, filesDeleted int64
+	var fileIDs []uuid.UUID
+
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		ids, err := subtreeIDs(tx, folder.ID)
+		if err != nil {
+			return err
+		}
+
+		err = tx.Model(&models.File{}).
+			Where("folder_id IN ?", ids).
+			Pluck("id", &fileIDs).Error
+		if err != nil {
+			return fmt.Errorf("failed to collect files: %v", err)
+		}
+
+		result := tx.Model(&models.File{}).Where("id IN ?", fileIDs).Updates(map[string]interface{}{
+			"deleted_at":     now,
+			"deleted_status": true,
+		})
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete files: %v", result.Error)
+		}
+		filesDeleted = result.RowsAffected
+
+		result = tx.Where("id IN ?", ids).Delete(&models.Folder{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete folders: %v", result.Error)
+		}
+		foldersDeleted = result.RowsAffected
+
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for _, fileID := range fileIDs {
+		cache.GetClient().Del(cache.Ctx, fileID.String())
+	}
+
+	return foldersDeleted, filesDeleted, nil
+}
+
+// MoveFile places a file in a folder, or at the root when folderID is nil.
+func MoveFile(file *models.File, folderID *uuid.UUID) error {
+	if err := checkParent(database.GetDB(), file.OwnerID, folderID); err != nil {
+		return err
+	}
+
+	err := database.GetDB().Model(file).Updates(map[string]interface{}{
+		"folder_id":  folderID,
+		"updated_at": time.Now(),
+	}).Error
+	if err != nil {
+		return fmt.Errorf("failed to move file: %v", err)
+	}
+
+	cache.GetClient().Del(cache.Ctx, file.ID.String())
+	return nil
+}
@@ -0,0 +1,372 @@
+package files
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/souvik150/file-sharing-app/internal/database"
+	"github.com/souvik150/file-sharing-app/internal/models"
+)
+
+var ErrInvalidImage = errors.New("image is malformed")
+
+// maxEXIFChunk bounds the PNG eXIf chunks read for their capture time.
+const maxEXIFChunk = 1 << 20
+
+// ImageMetadata is what is kept of an image's metadata.
+type ImageMetadata struct {
+	// Width and Height are the displayed dimensions, after rotation.
+	Width      int
+	Height     int
+	CapturedAt *time.Time
+	// Stripped is set when EXIF, XMP and text metadata were removed.
+	Stripped bool
+
+	orientation int
+}
+
+// PrepareImage reads the dimensions and capture time of a JPEG or PNG saved
+// at path and, when strip is set, rewrites it without EXIF, XMP, IPTC,
+// comments or text chunks. The image data itself is copied unchanged, and a
+// JPEG's orientation is kept so it still displays upright. Other types
+// return nil.
+func PrepareImage(path, mimeType string, strip bool) (*ImageMetadata, error) {
+	var scan func(*bufio.Reader, io.Writer, *ImageMetadata) error
+	switch mimeType {
+	case "image/jpeg":
+		scan = scanJPEG
+	case "image/png":
+		scan = scanPNG
+	default:
+		return nil, nil
+	}
+
+	in, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open image: %v", err)
+	}
+	defer in.Close()
+
+	meta := &ImageMetadata{orientation: 1}
+	if !strip {
+		if err := scan(bufio.NewReader(in), nil, meta); err != nil {
+			return nil, err
+		}
+		return meta.oriented(), nil
+	}
+
+	strippedPath := path + ".stripped"
+	out, err := os.Create(strippedPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stripped image: %v", err)
+	}
+	w := bufio.NewWriter(out)
+	err = scan(bufio.NewReader(in), w, meta)
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(strippedPath, path)
+	}
+	if err != nil {
+		os.Remove(strippedPath)
+		if errors.Is(err, ErrInvalidImage) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to strip image metadata: %v", err)
+	}
+
+	meta.Stripped = true
+	return meta.oriented(), nil
+}
+
+// oriented swaps the dimensions of images stored rotated by 90 degrees.
+func (m *ImageMetadata) oriented() *ImageMetadata {
+	if m.orientation >= 5 && m.orientation <= 8 {
+		m.Width, m.Height = m.Height, m.Width
+	}
+	return m
+}
+
+// scanJPEG walks the segments of a JPEG up to its image data. With a writer
+// it copies everything but the metadata segments; without one it stops at
+// the image data.
+func scanJPEG(r *bufio.Reader, w io.Writer, meta *ImageMetadata) error {
+	var start [2]byte
+	if _, err := io.ReadFull(r, start[:]); err != nil || start != [2]byte{0xFF, 0xD8} {
+		return fmt.Errorf("%w: missing JPEG start marker", ErrInvalidImage)
+	}
+	emit(w, start[:])
+
+	for {
+		b, err := r.ReadByte()
+		if err != nil || b != 0xFF {
+			return fmt.Errorf("%w: expected a JPEG marker", ErrInvalidImage)
+		}
+		marker := byte(0xFF)
+		for marker == 0xFF {
+			if marker, err = r.ReadByte(); err != nil {
+				return fmt.Errorf("%w: truncated JPEG marker", ErrInvalidImage)
+			}
+		}
+
+		if marker == 0xD9 || marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7) {
+			emit(w, []byte{0xFF, marker})
+			if marker == 0xD9 {
+				return nil
+			}
+			continue
+		}
+
+		var length [2]byte
+		if _, err := io.ReadFull(r, length[:]); err != nil {
+			return fmt.Errorf("%w: truncated JPEG segment", ErrInvalidImage)
+		}
+		size := int(binary.BigEndian.Uint16(length[:]))
+		if size < 2 {
+			return fmt.Errorf("%w: invalid JPEG segment length", ErrInvalidImage)
+		}
+		payload := make([]byte, size-2)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return fmt.Errorf("%w: truncated JPEG segment", ErrInvalidImage)
+		}
+
+		switch {
+		case marker == 0xE1:
+			// APP1 holds EXIF or XMP. Only the orientation survives.
+			if exif, ok := bytes.CutPrefix(payload, []byte("Exif\x00\x00")); ok {
+				parseEXIF(exif, meta)
+				if meta.orientation != 1 {
+					emit(w, orientationSegment(meta.orientation))
+				}
+			}
+			continue
+		case marker == 0xED || marker == 0xFE:
+			// APP13 holds IPTC and COM free text comments.
+			continue
+		case marker >= 0xC0 && marker <= 0xCF && marker != 0xC4 && marker != 0xC8 && marker != 0xCC:
+			if len(payload) < 5 {
+				return fmt.Errorf("%w: truncated JPEG frame header", ErrInvalidImage)
+			}
+			meta.Height = int(binary.BigEndian.Uint16(payload[1:3]))
+			meta.Width = int(binary.BigEndian.Uint16(payload[3:5]))
+		}
+
+		emit(w, []byte{0xFF, marker}, length[:], payload)
+		if marker == 0xDA {
+			// The entropy-coded data follows the scan header.
+			if w == nil {
+				return nil
+			}
+			_, err := io.Copy(w, r)
+			return err
+		}
+	}
+}
+
+// orientationSegment is an APP1 segment whose EXIF holds only the
+// orientation.
+func orientationSegment(orientation int) []byte {
+	exif := []byte{
+		'E', 'x', 'i', 'f', 0, 0,
+		'M', 'M', 0, 42, 0, 0, 0, 8, // big endian, first IFD at 8
+		0, 1, // one entry
+		0x01, 0x12, 0, 3, 0, 0, 0, 1, 0, byte(orientation), 0, 0, // orientation, one short
+		0, 0, 0, 0, // no further IFD
+	}
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(exif)+2))
+	return append(segment, exif...)
+}
+
+// scanPNG walks the chunks of a PNG. With a writer it copies everything but
+// eXIf and the text and time chunks; without one it stops at the image data.
+func scanPNG(r *bufio.Reader, w io.Writer, meta *ImageMetadata) error {
+	var signature [8]byte
+	if _, err := io.ReadFull(r, signature[:]); err != nil || string(signature[:]) != "\x89PNG\r\n\x1a\n" {
+		return fmt.Errorf("%w: missing PNG signature", ErrInvalidImage)
+	}
+	emit(w, signature[:])
+
+	for {
+		var header [8]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return fmt.Errorf("%w: truncated PNG chunk", ErrInvalidImage)
+		}
+		length := int64(binary.BigEndian.Uint32(header[:4]))
+		if length > 1<<31-1 {
+			return fmt.Errorf("%w: invalid PNG chunk length", ErrInvalidImage)
+		}
+		// The chunk's data is followed by its CRC.
+		size := length + 4
+
+		switch chunkType := string(header[4:]); chunkType {
+		case "IHDR", "eXIf":
+			if chunkType == "eXIf" && length > maxEXIFChunk {
+				if _, err := r.Discard(int(size)); err != nil {
+					return fmt.Errorf("%w: truncated PNG chunk", ErrInvalidImage)
+				}
+				continue
+			}
+			chunk := make([]byte, size)
+			if _, err := io.ReadFull(r, chunk); err != nil {
+				return fmt.Errorf("%w: truncated PNG chunk", ErrInvalidImage)
+			}
+			if chunkType == "eXIf" {
+				// Few viewers rotate PNGs, so only the capture time is used.
+				parseEXIF(chunk[:length], meta)
+				meta.orientation = 1
+				continue
+			}
+			if length < 8 {
+				return fmt.Errorf("%w: truncated PNG header", ErrInvalidImage)
+			}
+			meta.Width = int(binary.BigEndian.Uint32(chunk[0:4]))
+			meta.Height = int(binary.BigEndian.Uint32(chunk[4:8]))
+			emit(w, header[:], chunk)
+		case "tEXt", "zTXt", "iTXt", "tIME":
+			// XMP is stored in an iTXt chunk.
+			if _, err := r.Discard(int(size)); err != nil {
+				return fmt.Errorf("%w: truncated PNG chunk", ErrInvalidImage)
+			}
+		default:
+			if w == nil {
+				if chunkType == "IDAT" || chunkType == "IEND" {
+					return nil
+				}
+				if _, err := r.Discard(int(size)); err != nil {
+					return fmt.Errorf("%w: truncated PNG chunk", ErrInvalidImage)
+				}
+				continue
+			}
+			emit(w, header[:])
+			if _, err := io.CopyN(w, r, size); err != nil {
+				return fmt.Errorf("%w: truncated PNG chunk", ErrInvalidImage)
+			}
+			if chunkType == "IEND" {
+				return nil
+			}
+		}
+	}
+}
+
+func emit(w io.Writer, parts ...[]byte) {
+	if w == nil {
+		return
+	}
+	// Write errors stick in the bufio.Writer and surface when it is flushed.
+	for _, part := range parts {
+		w.Write(part)
+	}
+}
+
+// parseEXIF picks the orientation and capture time out of a TIFF-encoded
+// EXIF block, ignoring anything malformed.
+func parseEXIF(tiff []byte, meta *ImageMetadata) {
+	if len(tiff) < 8 {
+		return
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return
+	}
+
+	var dateTime, original, offset string
+	var exifIFD uint32
+	readIFD(tiff, order, order.Uint32(tiff[4:8]), func(tag uint16, entry []byte) {
+		switch tag {
+		case 0x0112:
+			if value := int(order.Uint16(entry[8:10])); value >= 1 && value <= 8 {
+				meta.orientation = value
+			}
+		case 0x0132:
+			dateTime = exifString(tiff, order, entry)
+		case 0x8769:
+			exifIFD = order.Uint32(entry[8:12])
+		}
+	})
+	if exifIFD != 0 {
+		readIFD(tiff, order, exifIFD, func(tag uint16, entry []byte) {
+			switch tag {
+			case 0x9003:
+				original = exifString(tiff, order, entry)
+			case 0x9011:
+				offset = exifString(tiff, order, entry)
+			}
+		})
+	}
+
+	if original == "" {
+		original, offset = dateTime, ""
+	}
+	// EXIF times are local to the camera; without an offset they are taken
+	// as UTC.
+	layout, value := "2006:01:02 15:04:05", original
+	if offset != "" {
+		layout, value = layout+"-07:00", original+offset
+	}
+	if capturedAt, err := time.Parse(layout, value); err == nil {
+		capturedAt = capturedAt.UTC()
+		meta.CapturedAt = &capturedAt
+	}
+}
+
+// readIFD calls visit with the tag and 12 byte entry of every field in the
+// IFD at offset.
+func readIFD(tiff []byte, order binary.ByteOrder, offset uint32, visit func(uint16, []byte)) {
+	if uint64(offset)+2 > uint64(len(tiff)) {
+		return
+	}
+	count := int(order.Uint16(tiff[offset:]))
+	start := int(offset) + 2
+	for i := 0; i < count; i++ {
+		entry := start + i*12
+		if entry+12 > len(tiff) {
+			return
+		}
+		visit(order.Uint16(tiff[entry:]), tiff[entry:entry+12])
+	}
+}
+
+// exifString reads an ASCII field, stored inline when it fits in four bytes.
+func exifString(tiff []byte, order binary.ByteOrder, entry []byte) string {
+	if order.Uint16(entry[2:4]) != 2 {
+		return ""
+	}
+	count := order.Uint32(entry[4:8])
+	value := entry[8:12]
+	if count > 4 {
+		offset := order.Uint32(entry[8:12])
+		if uint64(offset)+uint64(count) > uint64(len(tiff)) {
+			return ""
+		}
+		value = tiff[offset : offset+count]
+	} else {
+		value = value[:count]
+	}
+	return string(bytes.TrimRight(value, "\x00 "))
+}
+
+// SetStripImageMetadata changes whether metadata is stripped from the user's
+// image uploads by default.
+func SetStripImageMetadata(user *models.User, strip bool) error {
+	if err := database.GetDB().Model(user).Update("strip_image_metadata", strip).Error; err != nil {
+		return fmt.Errorf("failed to update image metadata setting: %v", err)
+	}
+	user.StripImageMetadata = strip
+	return nil
+}
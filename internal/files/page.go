@@ -0,0 +1,199 @@
+package files
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/souvik150/file-sharing-app/internal/models"
+)
+
+const (
+	SortName      = "name"
+	SortSize      = "size"
+	SortCreated   = "created"
+	SortAccessed  = "accessed"
+	SortRelevance = "relevance"
+)
+
+var ErrInvalidCursor = errors.New("cursor is invalid or was issued for a different sort")
+var ErrInvalidSort = errors.New("sort must be one of name, size, created, accessed or relevance")
+
+// PageRequest describes one page of a keyset paginated file listing. Search is
+// the full-text query and is required to sort by relevance.
+type PageRequest struct {
+	Sort       string
+	Descending bool
+	Limit      int
+	Cursor     string
+	Search     string
+}
+
+// Page is a page of files with opaque cursors to the pages around it. A
+// cursor is empty when there is no page in that direction.
+type Page struct {
+	Files      []models.File
+	NextCursor string
+	PrevCursor string
+}
+
+// cursor marks the row a page starts after. Value is the sort key of that row
+// as Postgres renders it in text, so it compares exactly when cast back.
+type cursor struct {
+	Sort       string    `json:"s"`
+	Descending bool      `json:"d"`
+	Value      string    `json:"v"`
+	ID         uuid.UUID `json:"i"`
+	Backward   bool      `json:"b,omitempty"`
+}
+
+// sortKey is the SQL expression a listing is ordered by, ties broken by ID.
+type sortKey struct {
+	sql    string
+	vars   []interface{}
+	castAs string
+}
+
+func (r PageRequest) key() (sortKey, error) {
+	switch r.Sort {
+	case SortName:
+		return sortKey{sql: "LOWER(files.file_name)", castAs: "text"}, nil
+	case SortSize:
+		return sortKey{sql: "files.size", castAs: "bigint"}, nil
+	case SortCreated:
+		return sortKey{sql: "files.created_at", castAs: "timestamptz"}, nil
+	case SortAccessed:
+		return sortKey{sql: "files.accessed_at", castAs: "timestamptz"}, nil
+	case SortRelevance:
+		if r.Search == "" {
+			return sortKey{}, ErrInvalidSort
+		}
+		return sortKey{
+			sql:    "ts_rank_cd(files.search_vector, websearch_to_tsquery('english', ?))",
+			vars:   []interface{}{r.Search},
+			castAs: "real",
+		}, nil
+	}
+	return sortKey{}, ErrInvalidSort
+}
+
+func encodeCursor(c cursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(value string) (*cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// Paginate runs a filtered query on files and returns the requested page.
+// Pages are found by comparing against the sort key of the row a cursor
+// points at, so they stay stable while files are added or removed.
+func Paginate(query *gorm.DB, request PageRequest) (*Page, error) {
+	key, err := request.key()
+	if err != nil {
+		return nil, err
+	}
+
+	var after *cursor
+	if request.Cursor != "" {
+		after, err = decodeCursor(request.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if after.Sort != request.Sort || after.Descending != request.Descending {
+			return nil, ErrInvalidCursor
+		}
+	}
+
+	// Walking backwards flips the comparison and the order; the rows are put
+	// back in display order afterwards.
+	backward := after != nil && after.Backward
+	descending := request.Descending != backward
+	comparison, direction := ">", "ASC"
+	if descending {
+		comparison, direction = "<", "DESC"
+	}
+
+	if after != nil {
+		vars := append(append([]interface{}{}, key.vars...), after.Value, after.ID)
+		query = query.Where(clause.Expr{
+			SQL:  fmt.Sprintf("(%s, files.id) %s (CAST(? AS %s), ?)", key.sql, comparison, key.castAs),
+			Vars: vars,
+		})
+	}
+
+	var rows []models.File
+	err = query.Order(clause.OrderBy{Expression: clause.Expr{
+		SQL:  fmt.Sprintf("%s %s, files.id %s", key.sql, direction, direction),
+		Vars: key.vars,
+	}}).Limit(request.Limit + 1).Find(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files: %v", err)
+	}
+
+	hasMore := len(rows) > request.Limit
+	if hasMore {
+		rows = rows[:request.Limit]
+	}
+	if backward {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+
+	page := &Page{Files: rows}
+	if len(rows) == 0 {
+		return page, nil
+	}
+
+	first, last := rows[0].ID, rows[len(rows)-1].ID
+	values, err := sortValues(query, key, []uuid.UUID{first, last})
+	if err != nil {
+		return nil, err
+	}
+
+	// Coming back from a later page means there is one; likewise for earlier.
+	if (!backward && hasMore) || backward {
+		page.NextCursor = encodeCursor(cursor{Sort: request.Sort, Descending: request.Descending, Value: values[last], ID: last})
+	}
+	if (!backward && after != nil) || (backward && hasMore) {
+		page.PrevCursor = encodeCursor(cursor{Sort: request.Sort, Descending: request.Descending, Value: values[first], ID: first, Backward: true})
+	}
+
+	return page, nil
+}
+
+// sortValues reads the sort key of the files as text for use in cursors.
+func sortValues(query *gorm.DB, key sortKey, fileIDs []uuid.UUID) (map[uuid.UUID]string, error) {
+	var rows []struct {
+		ID    uuid.UUID
+		Value string
+	}
+	vars := append(append([]interface{}{}, key.vars...), fileIDs)
+	err := query.Session(&gorm.Session{NewDB: true}).
+		Raw(fmt.Sprintf("SELECT files.id, CAST(%s AS text) AS value FROM files WHERE files.id IN ?", key.sql), vars...).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to build page cursors: %v", err)
+	}
+
+	values := make(map[uuid.UUID]string, len(rows))
+	for _, row := range rows {
+		values[row.ID] = row.Value
+	}
+	return values, nil
+}
@@ -0,0 +1,154 @@
+package files
+
+import (
+	"errors"
+	"slices"
+	"sort"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/souvik150/file-sharing-app/internal/database"
+	"github.com/souvik150/file-sharing-app/internal/models"
+	"github.com/souvik150/file-sharing-app/internal/testenv"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := []cursor{
+		{Sort: SortName, Value: "report.pdf", ID: uuid.New()},
+		{Sort: SortSize, Descending: true, Value: "1024", ID: uuid.New()},
+		{Sort: SortCreated, Value: "2024-05-01 10:00:00.123456+00", ID: uuid.New(), Backward: true},
+		{Sort: SortRelevance, Descending: true, Value: "0.1", ID: uuid.New()},
+		{Sort: SortName, Value: "\"quoted\", unicode ✓ and / slashes", ID: uuid.New()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Sort, func(t *testing.T) {
+			got, err := decodeCursor(encodeCursor(tt))
+			if err != nil {
+				t.Fatalf("decodeCursor: %v", err)
+			}
+			if *got != tt {
+				t.Errorf("got %+v, want %+v", *got, tt)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorRejectsGarbage(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{name: "not base64", value: "not a cursor!"},
+		{name: "padded base64", value: "eyJzIjoibmFtZSJ9=="},
+		{name: "not json", value: "bm90IGpzb24"},
+		{name: "wrong id", value: "eyJpIjoibm9wZSJ9"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := decodeCursor(tt.value); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("got error %v, want %v", err, ErrInvalidCursor)
+			}
+		})
+	}
+}
+
+func TestPaginateRejectsCursorForOtherSort(t *testing.T) {
+	issued := encodeCursor(cursor{Sort: SortName, Value: "a", ID: uuid.New()})
+
+	tests := []struct {
+		name    string
+		request PageRequest
+	}{
+		{name: "other sort", request: PageRequest{Sort: SortSize, Limit: 10, Cursor: issued}},
+		{name: "other direction", request: PageRequest{Sort: SortName, Descending: true, Limit: 10, Cursor: issued}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Paginate(nil, tt.request); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("got error %v, want %v", err, ErrInvalidCursor)
+			}
+		})
+	}
+}
+
+func TestPaginateBreaksTiesByID(t *testing.T) {
+	testenv.Setup(t)
+	user := testenv.CreateUser(t)
+
+	// Every file has the same size, so only the ID orders them.
+	var ids []uuid.UUID
+	for i := 0; i < 5; i++ {
+		file := models.File{ID: uuid.New(), FileName: "same.txt", OwnerID: user.ID, Size: 42}
+		if err := database.GetDB().Create(&file).Error; err != nil {
+			t.Fatalf("failed to create file: %v", err)
+		}
+		ids = append(ids, file.ID)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i].String() < ids[j].String() })
+
+	tests := []struct {
+		name       string
+		sort       string
+		descending bool
+	}{
+		{name: "size ascending", sort: SortSize},
+		{name: "size descending", sort: SortSize, descending: true},
+		{name: "name ascending", sort: SortName},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := append([]uuid.UUID{}, ids...)
+			if tt.descending {
+				for i, j := 0, len(want)-1; i < j; i, j = i+1, j-1 {
+					want[i], want[j] = want[j], want[i]
+				}
+			}
+
+			request := PageRequest{Sort: tt.sort, Descending: tt.descending, Limit: 2}
+			var pages []*Page
+			var got []uuid.UUID
+			for {
+				page, err := Paginate(database.GetDB().Model(&models.File{}).Where("owner_id = ?", user.ID), request)
+				if err != nil {
+					t.Fatalf("Paginate: %v", err)
+				}
+				pages = append(pages, page)
+				for _, file := range page.Files {
+					got = append(got, file.ID)
+				}
+				if page.NextCursor == "" {
+					break
+				}
+				request.Cursor = page.NextCursor
+			}
+			if !slices.Equal(got, want) {
+				t.Fatalf("walking forward got %v, want %v", got, want)
+			}
+
+			// Walking back from the last page yields the same pages.
+			for i := len(pages) - 1; i > 0; i-- {
+				request.Cursor = pages[i].PrevCursor
+				page, err := Paginate(database.GetDB().Model(&models.File{}).Where("owner_id = ?", user.ID), request)
+				if err != nil {
+					t.Fatalf("Paginate: %v", err)
+				}
+				if !slices.Equal(fileIDs(page.Files), fileIDs(pages[i-1].Files)) {
+					t.Errorf("page %d walking back got %v, want %v", i-1, fileIDs(page.Files), fileIDs(pages[i-1].Files))
+				}
+			}
+		})
+	}
+}
+
+func fileIDs(files []models.File) []uuid.UUID {
+	ids := make([]uuid.UUID, len(files))
+	for i, file := range files {
+		ids[i] = file.ID
+	}
+	return ids
+}
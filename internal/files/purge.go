@@ -0,0 +1,111 @@
+package files
+
+import (
+	"fmt"
+	"log"
+
+	"gorm.io/gorm"
+
+	"github.com/souvik150/file-sharing-app/internal/cache"
+	"github.com/souvik150/file-sharing-app/internal/database"
+	"github.com/souvik150/file-sharing-app/internal/models"
+	"github.com/souvik150/file-sharing-app/pkg/s3"
+)
+
+// Purge permanently removes a file: the storage of all its versions and its
+// thumbnails, every share link pointing at it, its cached metadata and
+// finally its database row. Objects owned by a single version are deleted
+// first so a failure never leaves an orphaned object behind a missing row;
+// deduplicated content is released and left to the blob collector, since
+// other files may share it.
+func Purge(file *models.File) error {
+	db := database.GetDB()
+
+	var versions []models.FileVersion
+	if err := db.Where("file_id = ?", file.ID).Find(&versions).Error; err != nil {
+		return fmt.Errorf("failed to load versions of file %s: %v", file.ID, err)
+	}
+
+	objectKeys := map[string]bool{}
+	for _, version := range versions {
+		if version.BlobID == nil {
+			objectKeys[version.ObjectKey] = true
+		}
+	}
+	// Files uploaded before versioning have a single object under their ID.
+	if len(versions) == 0 {
+		objectKeys[file.StorageKey()] = true
+		objectKeys[file.ID.String()] = true
+	}
+
+	// A version whose upload is still being processed has no blob yet, but
+	// its object may already be one. The blob collector removes those.
+	var blobKeys []string
+	if len(objectKeys) > 0 {
+		keys := make([]string, 0, len(objectKeys))
+		for key := range objectKeys {
+			keys = append(keys, key)
+		}
+		if err := db.Model(&models.Blob{}).Where("object_key IN ?", keys).Pluck("object_key", &blobKeys).Error; err != nil {
+			return fmt.Errorf("failed to check stored content of file %s: %v", file.ID, err)
+		}
+	}
+	for _, key := range blobKeys {
+		delete(objectKeys, key)
+	}
+
+	var preview models.FilePreview
+	if err := db.Where("file_id = ?", file.ID).Limit(1).Find(&preview).Error; err != nil {
+		return fmt.Errorf("failed to load preview of file %s: %v", file.ID, err)
+	}
+	for _, key := range thumbnailKeys(&preview) {
+		objectKeys[key] = true
+	}
+
+	for key := range objectKeys {
+		if err := s3.DeleteFileFromS3(key); err != nil {
+			return fmt.Errorf("failed to delete object %s for file %s: %v", key, file.ID, err)
+		}
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("file_id = ?", file.ID).Delete(&models.SharedLink{}).Error; err != nil {
+			return fmt.Errorf("failed to delete share links: %v", err)
+		}
+		if err := tx.Where("file_id = ?", file.ID).Delete(&models.FileVersion{}).Error; err != nil {
+			return fmt.Errorf("failed to delete versions: %v", err)
+		}
+		if err := releaseVersionBlobs(tx, versions); err != nil {
+			return err
+		}
+		if err := ReleaseStorage(tx, file.OwnerID, storedBytes(file, versions)); err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Delete(file).Error; err != nil {
+			return fmt.Errorf("failed to delete file record: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := cache.GetClient().Del(cache.Ctx, file.ID.String()).Err(); err != nil {
+		log.Printf("⚠️ Error removing file %s from cache: %v", file.ID, err)
+	}
+
+	log.Printf("🗑️ Purged file %s (%s)", file.ID, file.FileName)
+	return nil
+}
+
+// storedBytes is how much of its owner's quota a file takes up.
+func storedBytes(file *models.File, versions []models.FileVersion) int64 {
+	if len(versions) == 0 {
+		return file.Size
+	}
+	var total int64
+	for _, version := range versions {
+		total += version.Size
+	}
+	return total
+}
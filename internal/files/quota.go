@@ -0,0 +1,149 @@
+package files
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/souvik150/file-sharing-app/internal/config"
+	"github.com/souvik150/file-sharing-app/internal/database"
+	"github.com/souvik150/file-sharing-app/internal/models"
+)
+
+var ErrQuotaExceeded = errors.New("storage quota exceeded")
+var ErrInvalidQuota = errors.New("quota must be zero for unlimited or a positive number of bytes")
+
+// Quota is the number of bytes the user may store, 0 for unlimited.
+func Quota(user *models.User) int64 {
+	if user.StorageQuota != nil {
+		return *user.StorageQuota
+	}
+	return config.AppConfig.StorageQuota
+}
+
+// ReserveStorage adds bytes to the owner's usage ahead of storing them,
+// failing with ErrQuotaExceeded when they would not fit. The check and the
+// update are one statement, so concurrent uploads cannot overshoot together.
+// The bytes stay reserved until SettleReservation or ReleaseReservation.
+func ReserveStorage(ownerID uuid.UUID, bytes int64) error {
+	result := database.GetDB().Exec(`UPDATE users SET storage_used = storage_used + @bytes,
+		storage_reserved = storage_reserved + @bytes
+		WHERE id = @owner AND (COALESCE(storage_quota, @default) = 0 OR storage_used + @bytes <= COALESCE(storage_quota, @default))`,
+		map[string]interface{}{"bytes": bytes, "owner": ownerID, "default": config.AppConfig.StorageQuota})
+	if result.Error != nil {
+		return fmt.Errorf("failed to reserve storage: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrQuotaExceeded
+	}
+	return nil
+}
+
+// ReleaseStorage takes bytes off the owner's usage when they are deleted.
+func ReleaseStorage(db *gorm.DB, ownerID uuid.UUID, bytes int64) error {
+	err := db.Exec("UPDATE users SET storage_used = GREATEST(storage_used - ?, 0) WHERE id = ?", bytes, ownerID).Error
+	if err != nil {
+		return fmt.Errorf("failed to release storage: %v", err)
+	}
+	return nil
+}
+
+// releaseFileStorage takes bytes off the usage of a file's owner.
+func releaseFileStorage(tx *gorm.DB, fileID uuid.UUID, bytes int64) error {
+	err := tx.Exec(`UPDATE users SET storage_used = GREATEST(storage_used - ?, 0)
+		WHERE id = (SELECT owner_id FROM files WHERE id = ?)`, bytes, fileID).Error
+	if err != nil {
+		return fmt.Errorf("failed to release storage: %v", err)
+	}
+	return nil
+}
+
+// ReleaseReservation gives back reserved bytes that will not be stored. It
+// is called on failure paths, where the error can only be logged.
+func ReleaseReservation(ownerID uuid.UUID, bytes int64) {
+	err := database.GetDB().Exec(`UPDATE users SET storage_used = GREATEST(storage_used - ?, 0),
+		storage_reserved = GREATEST(storage_reserved - ?, 0) WHERE id = ?`, bytes, bytes, ownerID).Error
+	if err != nil {
+		log.Printf("⚠️ Error releasing %d reserved bytes of user %s: %v", bytes, ownerID, err)
+	}
+}
+
+// ResetReservations gives back the storage reserved by uploads that were in
+// progress when the server stopped. Those uploads are lost, so it must run at
+// startup before any upload is accepted.
+func ResetReservations() {
+	result := database.GetDB().Exec(`UPDATE users SET storage_used = GREATEST(storage_used - storage_reserved, 0),
+		storage_reserved = 0 WHERE storage_reserved > 0`)
+	if result.Error != nil {
+		log.Printf("❌ Error resetting reserved storage: %v", result.Error)
+		return
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("📊 Released the reserved storage of %d users", result.RowsAffected)
+	}
+}
+
+// FitsQuota reports whether bytes more would fit in the owner's quota right
+// now. It lets uploads that cannot fit be turned away before their body is
+// read; ReserveStorage still decides.
+func FitsQuota(ownerID uuid.UUID, bytes int64) (bool, error) {
+	var fits bool
+	err := database.GetDB().Raw(`SELECT COALESCE(storage_quota, @default) = 0 OR storage_used + @bytes <= COALESCE(storage_quota, @default)
+		FROM users WHERE id = @owner`,
+		map[string]interface{}{"bytes": bytes, "owner": ownerID, "default": config.AppConfig.StorageQuota}).Scan(&fits).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to check quota: %v", err)
+	}
+	return fits, nil
+}
+
+// SettleReservation marks reserved bytes as stored. It must run in the
+// transaction that records the version holding them, so ReconcileStorageUsage
+// counts the bytes exactly once.
+func SettleReservation(tx *gorm.DB, ownerID uuid.UUID, bytes int64) error {
+	err := tx.Exec("UPDATE users SET storage_reserved = GREATEST(storage_reserved - ?, 0) WHERE id = ?", bytes, ownerID).Error
+	if err != nil {
+		return fmt.Errorf("failed to settle reserved storage: %v", err)
+	}
+	return nil
+}
+
+// SetQuota overrides a user's quota. A nil quota returns the user to the
+// STORAGE_QUOTA default.
+func SetQuota(user *models.User, quota *int64) error {
+	if quota != nil && *quota < 0 {
+		return ErrInvalidQuota
+	}
+	if err := database.GetDB().Model(user).Update("storage_quota", quota).Error; err != nil {
+		return fmt.Errorf("failed to update quota: %v", err)
+	}
+	user.StorageQuota = quota
+	return nil
+}
+
+// ReconcileStorageUsage recomputes usage from the stored versions: the size
+// of every version of a user's files, in the trash or not, plus the storage
+// reserved by uploads in progress. It corrects drift in the running total,
+// such as updates lost to errors. Reservations abandoned by a restart are
+// given back by ResetReservations.
+func ReconcileStorageUsage() {
+	result := database.GetDB().Exec(`UPDATE users SET storage_used = usage.bytes + users.storage_reserved
+		FROM (
+			SELECT users.id, COALESCE(SUM(file_versions.size), 0) AS bytes
+			FROM users
+			LEFT JOIN files ON files.owner_id = users.id
+			LEFT JOIN file_versions ON file_versions.file_id = files.id
+			GROUP BY users.id
+		) usage
+		WHERE usage.id = users.id AND users.storage_used <> usage.bytes + users.storage_reserved`)
+	if result.Error != nil {
+		log.Printf("❌ Error reconciling storage usage: %v", result.Error)
+		return
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("📊 Corrected the storage usage of %d users", result.RowsAffected)
+	}
+}
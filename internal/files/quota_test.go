@@ -0,0 +1,121 @@
+package files
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/souvik150/file-sharing-app/internal/database"
+	"github.com/souvik150/file-sharing-app/internal/models"
+	"github.com/souvik150/file-sharing-app/internal/testenv"
+)
+
+func TestStorageReservationArithmetic(t *testing.T) {
+	testenv.Setup(t)
+	user := testenv.CreateUser(t)
+	quota := int64(100)
+	if err := SetQuota(&user, &quota); err != nil {
+		t.Fatalf("SetQuota: %v", err)
+	}
+
+	// Each step runs against the usage left by the steps before it.
+	steps := []struct {
+		name         string
+		run          func() error
+		wantErr      error
+		wantUsed     int64
+		wantReserved int64
+	}{
+		{
+			name:         "reserve",
+			run:          func() error { return ReserveStorage(user.ID, 60) },
+			wantUsed:     60,
+			wantReserved: 60,
+		},
+		{
+			name:         "reserve over quota",
+			run:          func() error { return ReserveStorage(user.ID, 41) },
+			wantErr:      ErrQuotaExceeded,
+			wantUsed:     60,
+			wantReserved: 60,
+		},
+		{
+			name:         "reserve up to quota",
+			run:          func() error { return ReserveStorage(user.ID, 40) },
+			wantUsed:     100,
+			wantReserved: 100,
+		},
+		{
+			name:         "settle",
+			run:          func() error { return SettleReservation(database.GetDB(), user.ID, 60) },
+			wantUsed:     100,
+			wantReserved: 40,
+		},
+		{
+			name:         "release",
+			run:          func() error { ReleaseReservation(user.ID, 30); return nil },
+			wantUsed:     70,
+			wantReserved: 10,
+		},
+		{
+			name:         "release more than reserved",
+			run:          func() error { ReleaseReservation(user.ID, 500); return nil },
+			wantUsed:     0,
+			wantReserved: 0,
+		},
+		{
+			name:         "reset after restart",
+			run:          func() error { ResetReservations(); return nil },
+			wantUsed:     0,
+			wantReserved: 0,
+		},
+	}
+
+	for _, step := range steps {
+		err := step.run()
+		if !errors.Is(err, step.wantErr) {
+			t.Fatalf("%s: got error %v, want %v", step.name, err, step.wantErr)
+		}
+
+		var stored models.User
+		if err := database.GetDB().First(&stored, "id = ?", user.ID).Error; err != nil {
+			t.Fatalf("%s: failed to load user: %v", step.name, err)
+		}
+		if stored.StorageUsed != step.wantUsed || stored.StorageReserved != step.wantReserved {
+			t.Fatalf("%s: got used %d reserved %d, want used %d reserved %d",
+				step.name, stored.StorageUsed, stored.StorageReserved, step.wantUsed, step.wantReserved)
+		}
+	}
+}
+
+func TestResetReservationsKeepsStoredBytes(t *testing.T) {
+	testenv.Setup(t)
+	user := testenv.CreateUser(t)
+
+	// 30 bytes were stored and 20 more were reserved by an upload the
+	// restart lost.
+	err := database.GetDB().Model(&user).Updates(map[string]interface{}{
+		"storage_used":     50,
+		"storage_reserved": 20,
+	}).Error
+	if err != nil {
+		t.Fatalf("failed to set usage: %v", err)
+	}
+
+	ResetReservations()
+
+	var stored models.User
+	if err := database.GetDB().First(&stored, "id = ?", user.ID).Error; err != nil {
+		t.Fatalf("failed to load user: %v", err)
+	}
+	if stored.StorageUsed != 30 || stored.StorageReserved != 0 {
+		t.Fatalf("got used %d reserved %d, want used 30 reserved 0", stored.StorageUsed, stored.StorageReserved)
+	}
+
+	fits, err := FitsQuota(user.ID, 1)
+	if err != nil {
+		t.Fatalf("FitsQuota: %v", err)
+	}
+	if !fits {
+		t.Fatalf("FitsQuota with the default unlimited quota = false, want true")
+	}
+}
@@ -0,0 +1,120 @@
+package files
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/souvik150/file-sharing-app/internal/config"
+	"github.com/souvik150/file-sharing-app/internal/database"
+	"github.com/souvik150/file-sharing-app/internal/models"
+	"github.com/souvik150/file-sharing-app/pkg/s3"
+)
+
+// scrubBatchSize bounds how many objects one run of Scrub reads back.
+const scrubBatchSize = 50
+
+// scrubGracePeriod keeps the scrub away from versions whose upload may still
+// be in progress.
+const scrubGracePeriod = time.Hour
+
+// Scrub reads back stored objects that are due for verification, checks that
+// they still decrypt and hash to the recorded SHA-256, and flags the versions
+// and files whose content is lost or damaged. Versions stored before hashing
+// get their digest from the first successful read.
+func Scrub() {
+	db := database.GetDB()
+
+	var due []models.FileVersion
+	err := db.Where("(verified_at IS NULL OR verified_at < ?) AND created_at < ?",
+		time.Now().Add(-config.AppConfig.ScrubInterval), time.Now().Add(-scrubGracePeriod)).
+		Order("verified_at NULLS FIRST").
+		Limit(scrubBatchSize).
+		Find(&due).Error
+	if err != nil {
+		log.Printf("❌ Error loading versions to scrub: %v", err)
+		return
+	}
+
+	// Deduplicated versions share an object, which only needs reading once.
+	checked := map[string]bool{}
+	verified, corrupted := 0, 0
+	for _, version := range due {
+		if checked[version.ObjectKey] {
+			continue
+		}
+		checked[version.ObjectKey] = true
+
+		digest, err := readDigest(version.ObjectKey)
+		if err != nil && !errors.Is(err, s3.ErrObjectNotFound) && !errors.Is(err, s3.ErrObjectUnreadable) {
+			log.Printf("⚠️ Error reading object %s, will retry: %v", version.ObjectKey, err)
+			continue
+		}
+
+		damaged := err != nil || (version.SHA256 != "" && digest != version.SHA256)
+		if err := RecordVerification(version.ObjectKey, digest, damaged); err != nil {
+			log.Printf("❌ Error recording verification of object %s: %v", version.ObjectKey, err)
+			continue
+		}
+		if damaged {
+			corrupted++
+			log.Printf("🚨 Object %s of file %s failed verification", version.ObjectKey, version.FileID)
+			continue
+		}
+		verified++
+	}
+
+	if verified > 0 || corrupted > 0 {
+		log.Printf("🔍 Scrub verified %d objects, %d corrupted", verified, corrupted)
+	}
+}
+
+func readDigest(objectKey string) (string, error) {
+	data, err := s3.DownloadFile(objectKey)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// RecordVerification stores the outcome of reading an object back on every
+// version and file using it. The digest of an intact object fills in versions
+// and files that have none yet.
+func RecordVerification(objectKey, digest string, corrupted bool) error {
+	return database.GetDB().Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.FileVersion{}).
+			Where("object_key = ?", objectKey).
+			UpdateColumns(map[string]interface{}{"verified_at": time.Now(), "corrupted": corrupted}).Error
+		if err != nil {
+			return fmt.Errorf("failed to update versions: %v", err)
+		}
+
+		// Files uploaded before versioning keep their object under their ID.
+		current := tx.Unscoped().Model(&models.File{}).
+			Where("COALESCE(NULLIF(object_key, ''), CAST(id AS text)) = ?", objectKey).
+			Session(&gorm.Session{})
+		if err := current.UpdateColumn("corrupted", corrupted).Error; err != nil {
+			return fmt.Errorf("failed to update files: %v", err)
+		}
+
+		if corrupted || digest == "" {
+			return nil
+		}
+		err = tx.Model(&models.FileVersion{}).
+			Where("object_key = ? AND COALESCE(sha256, '') = ''", objectKey).
+			UpdateColumn("sha256", digest).Error
+		if err != nil {
+			return fmt.Errorf("failed to record version checksums: %v", err)
+		}
+		if err := current.Where("COALESCE(sha256, '') = ''").UpdateColumn("sha256", digest).Error; err != nil {
+			return fmt.Errorf("failed to record file checksums: %v", err)
+		}
+		return nil
+	})
+}
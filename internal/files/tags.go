@@ -0,0 +1,281 @@
+package files
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/souvik150/file-sharing-app/internal/database"
+	"github.com/souvik150/file-sharing-app/internal/models"
+	"github.com/souvik150/file-sharing-app/internal/search"
+)
+
+const (
+	maxTagLength           = 64
+	maxTagsPerFile         = 50
+	maxMetadataKeyLength   = 64
+	maxMetadataValueLength = 1024
+	maxMetadataPerFile     = 50
+)
+
+var ErrFilesNotFound = errors.New("one or more files were not found")
+var ErrInvalidTag = errors.New("tags must be 1-64 characters of letters, digits, '-', '_', '.' or ':'")
+var ErrInvalidMetadataKey = errors.New("metadata keys must be 1-64 characters of letters, digits, '-', '_', '.' or ':'")
+var ErrInvalidMetadataValue = errors.New("metadata values must be at most 1024 characters")
+var ErrTooManyTags = fmt.Errorf("a file can have at most %d tags", maxTagsPerFile)
+var ErrTooManyMetadata = fmt.Errorf("a file can have at most %d metadata entries", maxMetadataPerFile)
+
+func validIdentifier(value string, maxLength int) bool {
+	if value == "" || len(value) > maxLength {
+		return false
+	}
+	for _, r := range value {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("-_.:", r) {
+			return false
+		}
+	}
+	return true
+}
+
+// NormalizeTag trims and lowercases a tag so "Invoices" and "invoices " are
+// the same tag.
+func NormalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if !validIdentifier(tag, maxTagLength) {
+		return "", ErrInvalidTag
+	}
+	return tag, nil
+}
+
+func normalizeTags(tags []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var normalized []string
+	for _, tag := range tags {
+		tag, err := NormalizeTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized, nil
+}
+
+// NormalizeMetadataKey trims a metadata key. Keys are case sensitive.
+func NormalizeMetadataKey(key string) (string, error) {
+	key = strings.TrimSpace(key)
+	if !validIdentifier(key, maxMetadataKeyLength) {
+		return "", ErrInvalidMetadataKey
+	}
+	return key, nil
+}
+
+// ownedFiles checks that every file exists, is not in the trash and belongs to the owner,
+// and returns the de-duplicated IDs.
+func ownedFiles(tx *gorm.DB, ownerID uuid.UUID, fileIDs []uuid.UUID) ([]uuid.UUID, error) {
+	unique := make(map[uuid.UUID]bool)
+	for _, id := range fileIDs {
+		unique[id] = true
+	}
+
+	var found []uuid.UUID
+	err := tx.Model(&models.File{}).
+		Where("id IN ? AND owner_id = ?", fileIDs, ownerID).
+		Pluck("id", &found).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load files: %v", err)
+	}
+	if len(found) != len(unique) {
+		return nil, ErrFilesNotFound
+	}
+	return found, nil
+}
+
+// exceedsLimit reports whether any of the files has more than limit rows in
+// the table after a change.
+func exceedsLimit(tx *gorm.DB, table string, fileIDs []uuid.UUID, limit int) (bool, error) {
+	var over bool
+	err := tx.Raw(`SELECT EXISTS (SELECT 1 FROM `+table+` WHERE file_id IN ?
+		GROUP BY file_id HAVING COUNT(*) > ?)`, fileIDs, limit).
+		Scan(&over).Error
+	return over, err
+}
+
+func touchFiles(tx *gorm.DB, fileIDs []uuid.UUID) error {
+	return tx.Model(&models.File{}).Where("id IN ?", fileIDs).Update("updated_at", time.Now()).Error
+}
+
+// UpdateTags adds and removes tags on several files at once. Either every
+// file is updated or none is.
+func UpdateTags(ownerID uuid.UUID, fileIDs []uuid.UUID, add, remove []string) error {
+	add, err := normalizeTags(add)
+	if err != nil {
+		return err
+	}
+	remove, err = normalizeTags(remove)
+	if err != nil {
+		return err
+	}
+
+	return database.GetDB().Transaction(func(tx *gorm.DB) error {
+		ids, err := ownedFiles(tx, ownerID, fileIDs)
+		if err != nil {
+			return err
+		}
+
+		if len(remove) > 0 {
+			err := tx.Where("file_id IN ? AND tag IN ?", ids, remove).Delete(&models.FileTag{}).Error
+			if err != nil {
+				return fmt.Errorf("failed to remove tags: %v", err)
+			}
+		}
+
+		if len(add) > 0 {
+			var rows []models.FileTag
+			for _, id := range ids {
+				for _, tag := range add {
+					rows = append(rows, models.FileTag{FileID: id, Tag: tag})
+				}
+			}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error; err != nil {
+				return fmt.Errorf("failed to add tags: %v", err)
+			}
+
+			over, err := exceedsLimit(tx, "file_tags", ids, maxTagsPerFile)
+			if err != nil {
+				return fmt.Errorf("failed to count tags: %v", err)
+			}
+			if over {
+				return ErrTooManyTags
+			}
+		}
+
+		if err := touchFiles(tx, ids); err != nil {
+			return fmt.Errorf("failed to update files: %v", err)
+		}
+		return search.Refresh(tx, ids)
+	})
+}
+
+// UpdateMetadata sets and removes metadata keys on several files at once.
+// Setting an existing key replaces its value. Either every file is updated or
+// none is.
+func UpdateMetadata(ownerID uuid.UUID, fileIDs []uuid.UUID, set map[string]string, remove []string) error {
+	normalizedSet := make(map[string]string)
+	for key, value := range set {
+		key, err := NormalizeMetadataKey(key)
+		if err != nil {
+			return err
+		}
+		if len(value) > maxMetadataValueLength {
+			return ErrInvalidMetadataValue
+		}
+		normalizedSet[key] = value
+	}
+	var normalizedRemove []string
+	for _, key := range remove {
+		key, err := NormalizeMetadataKey(key)
+		if err != nil {
+			return err
+		}
+		normalizedRemove = append(normalizedRemove, key)
+	}
+
+	return database.GetDB().Transaction(func(tx *gorm.DB) error {
+		ids, err := ownedFiles(tx, ownerID, fileIDs)
+		if err != nil {
+			return err
+		}
+
+		if len(normalizedRemove) > 0 {
+			err := tx.Where("file_id IN ? AND key IN ?", ids, normalizedRemove).Delete(&models.FileMetadata{}).Error
+			if err != nil {
+				return fmt.Errorf("failed to remove metadata: %v", err)
+			}
+		}
+
+		if len(normalizedSet) > 0 {
+			var rows []models.FileMetadata
+			for _, id := range ids {
+				for key, value := range normalizedSet {
+					rows = append(rows, models.FileMetadata{FileID: id, Key: key, Value: value})
+				}
+			}
+			err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "file_id"}, {Name: "key"}},
+				DoUpdates: clause.AssignmentColumns([]string{"value"}),
+			}).Create(&rows).Error
+			if err != nil {
+				return fmt.Errorf("failed to set metadata: %v", err)
+			}
+
+			over, err := exceedsLimit(tx, "file_metadata", ids, maxMetadataPerFile)
+			if err != nil {
+				return fmt.Errorf("failed to count metadata: %v", err)
+			}
+			if over {
+				return ErrTooManyMetadata
+			}
+		}
+
+		if err := touchFiles(tx, ids); err != nil {
+			return fmt.Errorf("failed to update files: %v", err)
+		}
+		return nil
+	})
+}
+
+// WithTags limits a query on files to those carrying the tags. With matchAll
+// a file needs every tag, otherwise any one of them.
+func WithTags(tags []string, matchAll bool) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if len(tags) == 0 {
+			return db
+		}
+		if matchAll {
+			return db.Where(`files.id IN (SELECT file_id FROM file_tags WHERE tag IN ?
+				GROUP BY file_id HAVING COUNT(*) = ?)`, tags, len(tags))
+		}
+		return db.Where("EXISTS (SELECT 1 FROM file_tags WHERE file_tags.file_id = files.id AND tag IN ?)", tags)
+	}
+}
+
+// WithMetadata limits a query on files to those having every key set to the
+// given value.
+func WithMetadata(pairs map[string]string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		for key, value := range pairs {
+			db = db.Where(`EXISTS (SELECT 1 FROM file_metadata WHERE file_metadata.file_id = files.id
+				AND file_metadata.key = ? AND file_metadata.value = ?)`, key, value)
+		}
+		return db
+	}
+}
+
+// TagNames returns the preloaded tags of a file, sorted.
+func TagNames(file *models.File) []string {
+	tags := make([]string, 0, len(file.Tags))
+	for _, tag := range file.Tags {
+		tags = append(tags, tag.Tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// MetadataMap returns the preloaded metadata of a file as a map.
+func MetadataMap(file *models.File) map[string]string {
+	metadata := make(map[string]string, len(file.Metadata))
+	for _, entry := range file.Metadata {
+		metadata[entry.Key] = entry.Value
+	}
+	return metadata
+}
@@ -0,0 +1,273 @@
+package files
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+	"gorm.io/gorm/clause"
+
+	"github.com/souvik150/file-sharing-app/internal/config"
+	"github.com/souvik150/file-sharing-app/internal/database"
+	"github.com/souvik150/file-sharing-app/internal/models"
+	"github.com/souvik150/file-sharing-app/pkg/s3"
+)
+
+// ThumbnailSizes are the bounding boxes, in pixels, thumbnails are generated
+// for. Images smaller than a box are not enlarged.
+var ThumbnailSizes = []int{128, 256, 512}
+
+// maxThumbnailPixels keeps small files that decode to huge images from
+// exhausting memory.
+const maxThumbnailPixels = 50_000_000
+
+// thumbnailBatchSize bounds how many files one run of
+// GeneratePendingThumbnails handles.
+const thumbnailBatchSize = 50
+
+// thumbnailGracePeriod keeps the thumbnail job away from files whose upload
+// to S3 may still be in progress.
+const thumbnailGracePeriod = 10 * time.Minute
+
+var previewTypes = []string{"image/jpeg", "image/png", "image/gif", "image/webp"}
+var previewExtensions = []string{"jpg", "jpeg", "png", "gif", "webp"}
+
+var ErrThumbnailNotFound = errors.New("thumbnail not available")
+
+// Previewable reports whether thumbnails can be generated for the file.
+// Files uploaded before type sniffing are judged by their extension.
+func Previewable(file *models.File) bool {
+	if file.MimeType == "" {
+		return slices.Contains(previewExtensions, strings.ToLower(file.FileType))
+	}
+	return slices.Contains(previewTypes, file.MimeType)
+}
+
+// ThumbnailSize picks the smallest generated size covering the requested one.
+func ThumbnailSize(requested int) int {
+	for _, size := range ThumbnailSizes {
+		if size >= requested {
+			return size
+		}
+	}
+	return ThumbnailSizes[len(ThumbnailSizes)-1]
+}
+
+func thumbnailKey(preview *models.FilePreview, size int) string {
+	return fmt.Sprintf("thumbnails/%s/%d/%d", preview.FileID, preview.Version, size)
+}
+
+// thumbnailKeys lists the objects stored for a preview.
+func thumbnailKeys(preview *models.FilePreview) []string {
+	if preview.Status != models.PreviewReady {
+		return nil
+	}
+	keys := make([]string, 0, len(ThumbnailSizes))
+	for _, size := range ThumbnailSizes {
+		keys = append(keys, thumbnailKey(preview, size))
+	}
+	return keys
+}
+
+// ThumbnailURLs maps each size to the URL of its thumbnail, or is nil when
+// the current version has none. The file's Preview must be loaded. The
+// version in the URL changes with the content, so clients may cache them.
+func ThumbnailURLs(file *models.File) map[string]string {
+	preview := file.Preview
+	if preview == nil || preview.Status != models.PreviewReady || preview.Version != file.CurrentVersion {
+		return nil
+	}
+	urls := make(map[string]string, len(ThumbnailSizes))
+	for _, size := range ThumbnailSizes {
+		urls[strconv.Itoa(size)] = fmt.Sprintf("/files/%s/thumbnail?size=%d&v=%d", file.ID, size, preview.Version)
+	}
+	return urls
+}
+
+// Thumbnail returns a thumbnail of the file's current version and the
+// preview it belongs to.
+func Thumbnail(file *models.File, size int) ([]byte, *models.FilePreview, error) {
+	var preview models.FilePreview
+	err := database.GetDB().
+		Where("file_id = ? AND version = ? AND status = ?", file.ID, file.CurrentVersion, models.PreviewReady).
+		Limit(1).Find(&preview).Error
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load preview of file %s: %v", file.ID, err)
+	}
+	if preview.Status == "" {
+		return nil, nil, ErrThumbnailNotFound
+	}
+
+	data, err := s3.DownloadFile(thumbnailKey(&preview, size))
+	if errors.Is(err, s3.ErrObjectNotFound) {
+		return nil, nil, ErrThumbnailNotFound
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to download thumbnail of file %s: %v", file.ID, err)
+	}
+	return data, &preview, nil
+}
+
+// GenerateThumbnails renders and stores the thumbnails of a file's current
+// version, replacing those of earlier versions. Files that cannot be
+// previewed are recorded too, so they are not tried again.
+func GenerateThumbnails(file *models.File) error {
+	db := database.GetDB()
+
+	var previous models.FilePreview
+	if err := db.Where("file_id = ?", file.ID).Limit(1).Find(&previous).Error; err != nil {
+		return fmt.Errorf("failed to load preview of file %s: %v", file.ID, err)
+	}
+
+	preview := models.FilePreview{
+		FileID:      file.ID,
+		Version:     file.CurrentVersion,
+		Status:      models.PreviewUnsupported,
+		GeneratedAt: time.Now(),
+	}
+
+	var renderErr error
+	if Previewable(file) && file.Size <= config.AppConfig.ThumbnailMaxFileSize {
+		renderErr = renderThumbnails(file, &preview)
+		if renderErr != nil {
+			preview.Status = models.PreviewFailed
+		}
+	}
+
+	err := db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&preview).Error
+	if err != nil {
+		return fmt.Errorf("failed to record preview of file %s: %v", file.ID, err)
+	}
+
+	if previous.Status != "" && previous.Version != preview.Version {
+		for _, key := range thumbnailKeys(&previous) {
+			if err := s3.DeleteFileFromS3(key); err != nil {
+				log.Printf("⚠️ Error deleting old thumbnail %s: %v", key, err)
+			}
+		}
+	}
+	return renderErr
+}
+
+// renderThumbnails decodes the file and stores a thumbnail for every size.
+func renderThumbnails(file *models.File, preview *models.FilePreview) error {
+	data, err := s3.DownloadFile(file.StorageKey())
+	if err != nil {
+		return fmt.Errorf("failed to download file %s: %v", file.ID, err)
+	}
+
+	imageConfig, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to read image %s: %v", file.ID, err)
+	}
+	if imageConfig.Width*imageConfig.Height > maxThumbnailPixels {
+		return fmt.Errorf("image %s is %dx%d, too large to preview", file.ID, imageConfig.Width, imageConfig.Height)
+	}
+
+	source, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to decode image %s: %v", file.ID, err)
+	}
+	bounds := source.Bounds()
+	preview.Width, preview.Height = bounds.Dx(), bounds.Dy()
+
+	// Transparency only survives in PNG; everything else is smaller as JPEG.
+	preview.Format = "png"
+	if opaque, ok := source.(interface{ Opaque() bool }); ok && opaque.Opaque() {
+		preview.Format = "jpeg"
+	}
+
+	for _, size := range ThumbnailSizes {
+		width, height := fitWithin(preview.Width, preview.Height, size)
+		thumbnail := image.NewRGBA(image.Rect(0, 0, width, height))
+		draw.CatmullRom.Scale(thumbnail, thumbnail.Bounds(), source, bounds, draw.Src, nil)
+
+		var encoded bytes.Buffer
+		if preview.Format == "jpeg" {
+			err = jpeg.Encode(&encoded, thumbnail, &jpeg.Options{Quality: 85})
+		} else {
+			err = png.Encode(&encoded, thumbnail)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to encode thumbnail of file %s: %v", file.ID, err)
+		}
+
+		if err := s3.PutObject(thumbnailKey(preview, size), encoded.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fitWithin scales width and height down to fit a size by size box, keeping
+// the aspect ratio.
+func fitWithin(width, height, size int) (int, int) {
+	if width <= size && height <= size {
+		return width, height
+	}
+	if width >= height {
+		return size, max(1, height*size/width)
+	}
+	return max(1, width*size/height), size
+}
+
+// GenerateThumbnailsFor previews freshly uploaded files by ID, logging
+// failures.
+func GenerateThumbnailsFor(fileIDs []string) {
+	var uploaded []models.File
+	if err := database.GetDB().Where("id IN ?", fileIDs).Find(&uploaded).Error; err != nil {
+		log.Printf("❌ Error loading files to preview: %v", err)
+		return
+	}
+
+	for i := range uploaded {
+		if !Previewable(&uploaded[i]) {
+			continue
+		}
+		if err := GenerateThumbnails(&uploaded[i]); err != nil {
+			log.Printf("❌ Error generating thumbnails of file %s: %v", uploaded[i].ID, err)
+			continue
+		}
+		log.Printf("🖼️ Generated thumbnails of file %s", uploaded[i].ID)
+	}
+}
+
+// GeneratePendingThumbnails brings previews up to date: images without
+// thumbnails of their current version, and files whose thumbnails belong to
+// a version that has since been replaced.
+func GeneratePendingThumbnails() {
+	var pending []models.File
+	err := database.GetDB().
+		Where("(sha256 <> '' OR created_at < ?)", time.Now().Add(-thumbnailGracePeriod)).
+		Where(`(mime_type IN ? OR (mime_type = '' AND LOWER(file_type) IN ?)
+			OR EXISTS (SELECT 1 FROM file_previews WHERE file_previews.file_id = files.id))`, previewTypes, previewExtensions).
+		Where("NOT EXISTS (SELECT 1 FROM file_previews WHERE file_previews.file_id = files.id AND file_previews.version = files.current_version)").
+		Order("created_at").
+		Limit(thumbnailBatchSize).
+		Find(&pending).Error
+	if err != nil {
+		log.Printf("❌ Error loading files to preview: %v", err)
+		return
+	}
+
+	for i := range pending {
+		if err := GenerateThumbnails(&pending[i]); err != nil {
+			log.Printf("❌ Error generating thumbnails of file %s: %v", pending[i].ID, err)
+		}
+	}
+
+	if len(pending) > 0 {
+		log.Printf("🖼️ Thumbnail job processed %d files", len(pending))
+	}
+}
@@ -0,0 +1,141 @@
+package files
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/souvik150/file-sharing-app/internal/cache"
+	"github.com/souvik150/file-sharing-app/internal/config"
+	"github.com/souvik150/file-sharing-app/internal/database"
+	"github.com/souvik150/file-sharing-app/internal/models"
+)
+
+var ErrFileNotFound = errors.New("file not found")
+var ErrNotInTrash = errors.New("file is not in the trash")
+
+// PurgeAt is when a trashed file is removed for good.
+func PurgeAt(file *models.File) time.Time {
+	return file.DeletedAt.Time.Add(config.AppConfig.TrashRetention)
+}
+
+// Trash moves a file to the trash. It disappears from listings and its share
+// links stop working, but it can be restored until the retention period ends.
+func Trash(file *models.File) error {
+	now := time.Now()
+	err := database.GetDB().Model(file).Updates(map[string]interface{}{
+		"deleted_at":     now,
+		"deleted_status": true,
+	}).Error
+	if err != nil {
+		return fmt.Errorf("failed to move file to trash: %v", err)
+	}
+
+	file.DeletedAt = gorm.DeletedAt{Time: now, Valid: true}
+	file.DeletedStatus = true
+	cache.GetClient().Del(cache.Ctx, file.ID.String())
+	return nil
+}
+
+// GetTrashedFile loads a file in the owner's trash.
+func GetTrashedFile(ownerID, fileID uuid.UUID) (*models.File, error) {
+	var file models.File
+	err := database.GetDB().Unscoped().
+		Where("id = ? AND owner_id = ?", fileID, ownerID).
+		First(&file).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrFileNotFound
+		}
+		return nil, fmt.Errorf("failed to load file: %v", err)
+	}
+	if !file.DeletedAt.Valid {
+		return nil, ErrNotInTrash
+	}
+	return &file, nil
+}
+
+// Restore takes a file out of the trash. A file whose folder was deleted in
+// the meantime is restored to the root.
+func Restore(file *models.File) error {
+	if file.FolderID != nil {
+		if _, err := GetFolder(file.OwnerID, *file.FolderID); errors.Is(err, ErrFolderNotFound) {
+			file.FolderID = nil
+		} else if err != nil {
+			return err
+		}
+	}
+
+	err := database.GetDB().Unscoped().Model(file).Updates(map[string]interface{}{
+		"deleted_at":     nil,
+		"deleted_status": false,
+		"folder_id":      file.FolderID,
+		"updated_at":     time.Now(),
+	}).Error
+	if err != nil {
+		return fmt.Errorf("failed to restore file: %v", err)
+	}
+
+	file.DeletedAt = gorm.DeletedAt{}
+	file.DeletedStatus = false
+	return nil
+}
+
+// EmptyTrash permanently deletes every file in the owner's trash and returns
+// how many files and bytes were removed. It stops at the first failure; the
+// files purged before it stay purged.
+func EmptyTrash(ownerID uuid.UUID) (int, int64, error) {
+	var trashed []models.File
+	err := database.GetDB().Unscoped().
+		Where("owner_id = ? AND deleted_at IS NOT NULL", ownerID).
+		Find(&trashed).Error
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to load trash: %v", err)
+	}
+
+	var count int
+	var bytes int64
+	for i := range trashed {
+		if err := Purge(&trashed[i]); err != nil {
+			return count, bytes, err
+		}
+		count++
+		bytes += trashed[i].Size
+	}
+	return count, bytes, nil
+}
+
+// PurgeExpiredTrash permanently deletes files that have been in the trash
+// longer than TRASH_RETENTION, and the deleted folders that held them.
+func PurgeExpiredTrash() {
+	db := database.GetDB()
+	cutoff := time.Now().Add(-config.AppConfig.TrashRetention)
+
+	var expired []models.File
+	if err := db.Unscoped().Where("deleted_at < ?", cutoff).Find(&expired).Error; err != nil {
+		log.Printf("❌ Error loading expired trash: %v", err)
+		return
+	}
+
+	purged := 0
+	for i := range expired {
+		if err := Purge(&expired[i]); err != nil {
+			log.Printf("❌ Error purging file %s from trash: %v", expired[i].ID, err)
+			continue
+		}
+		purged++
+	}
+
+	result := db.Unscoped().Where("deleted_at < ?", cutoff).Delete(&models.Folder{})
+	if result.Error != nil {
+		log.Printf("❌ Error purging deleted folders: %v", result.Error)
+	}
+
+	if purged > 0 || result.RowsAffected > 0 {
+		log.Printf("🗑️ Trash purge removed %d files and %d folders", purged, result.RowsAffected)
+	}
+}
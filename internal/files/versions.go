@@ -0,0 +1,279 @@
+package files
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/souvik150/file-sharing-app/internal/cache"
+	"github.com/souvik150/file-sharing-app/internal/config"
+	"github.com/souvik150/file-sharing-app/internal/database"
+	"github.com/souvik150/file-sharing-app/internal/models"
+	"github.com/souvik150/file-sharing-app/pkg/s3"
+)
+
+// retentionBatchSize bounds how many versions one run of
+// EnforceVersionRetention removes.
+const retentionBatchSize = 500
+
+var ErrVersionNotFound = errors.New("version not found")
+var ErrInvalidRetention = errors.New("retention must keep between 1 and 1000 versions, or between 1 and 3650 days")
+
+func ListVersions(fileID uuid.UUID) ([]models.FileVersion, error) {
+	var versions []models.FileVersion
+	err := database.GetDB().Where("file_id = ?", fileID).Order("version DESC").Find(&versions).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list versions: %v", err)
+	}
+	return versions, nil
+}
+
+func GetVersion(fileID uuid.UUID, version int) (*models.FileVersion, error) {
+	var fileVersion models.FileVersion
+	err := database.GetDB().Where("file_id = ? AND version = ?", fileID, version).First(&fileVersion).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrVersionNotFound
+		}
+		return nil, fmt.Errorf("failed to load version: %v", err)
+	}
+	return &fileVersion, nil
+}
+
+// AddVersion records an uploaded object as the newest version of a file and
+// makes it current, settling the storage reserved for it. The file row is
+// locked so concurrent uploads get distinct version numbers.
+func AddVersion(file *models.File, version models.FileVersion) (*models.FileVersion, error) {
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(file, "id = ?", file.ID).Error; err != nil {
+			return fmt.Errorf("failed to lock file: %v", err)
+		}
+
+		var latest int
+		err := tx.Model(&models.FileVersion{}).
+			Where("file_id = ?", file.ID).
+			Select("COALESCE(MAX(version), 0)").
+			Scan(&latest).Error
+		if err != nil {
+			return fmt.Errorf("failed to find latest version: %v", err)
+		}
+
+		version.ID = uuid.New()
+		version.FileID = file.ID
+		version.Version = latest + 1
+		if err := tx.Create(&version).Error; err != nil {
+			return fmt.Errorf("failed to create version: %v", err)
+		}
+		if err := SettleReservation(tx, file.OwnerID, version.Size); err != nil {
+			return err
+		}
+
+		file.ObjectKey = version.ObjectKey
+		file.SHA256 = version.SHA256
+		file.Corrupted = version.Corrupted
+		file.CurrentVersion = version.Version
+		file.Size = version.Size
+		file.FileType = version.FileType
+		file.MimeType = version.MimeType
+		file.TypeMismatch = version.TypeMismatch
+		file.ImageWidth = version.ImageWidth
+		file.ImageHeight = version.ImageHeight
+		file.CapturedAt = version.CapturedAt
+		file.MetadataStripped = version.MetadataStripped
+		file.UpdatedAt = time.Now()
+		err = tx.Model(file).Select("object_key", "sha256", "corrupted", "current_version", "size", "file_type", "mime_type", "type_mismatch",
+			"image_width", "image_height", "captured_at", "metadata_stripped", "updated_at").Updates(file).Error
+		if err != nil {
+			return fmt.Errorf("failed to update file: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cache.GetClient().Del(cache.Ctx, file.ID.String())
+	return &version, nil
+}
+
+// RestoreVersion makes an old version current again by adding it as a new
+// version, so the history stays linear. Versions stored as blobs share the
+// blob; older versions that own their object are copied. The new version
+// counts against the owner's quota like an upload.
+func RestoreVersion(file *models.File, old *models.FileVersion) (*models.FileVersion, error) {
+	if err := ReserveStorage(file.OwnerID, old.Size); err != nil {
+		return nil, err
+	}
+	var version *models.FileVersion
+	var err error
+	if old.BlobID != nil {
+		version, err = restoreBlobVersion(file, old)
+	} else {
+		version, err = restoreCopiedVersion(file, old)
+	}
+	if err != nil {
+		ReleaseReservation(file.OwnerID, old.Size)
+		return nil, err
+	}
+	return version, nil
+}
+
+func restoreCopiedVersion(file *models.File, old *models.FileVersion) (*models.FileVersion, error) {
+	objectKey := uuid.New().String()
+	if err := s3.CopyObject(old.ObjectKey, objectKey); err != nil {
+		return nil, fmt.Errorf("failed to copy version %d: %v", old.Version, err)
+	}
+
+	restoredFrom := old.Version
+	version, err := AddVersion(file, models.FileVersion{
+		ObjectKey:        objectKey,
+		SHA256:           old.SHA256,
+		Corrupted:        old.Corrupted,
+		FileName:         old.FileName,
+		FileType:         old.FileType,
+		MimeType:         old.MimeType,
+		TypeMismatch:     old.TypeMismatch,
+		Size:             old.Size,
+		ImageWidth:       old.ImageWidth,
+		ImageHeight:      old.ImageHeight,
+		CapturedAt:       old.CapturedAt,
+		MetadataStripped: old.MetadataStripped,
+		RestoredFrom:     &restoredFrom,
+	})
+	if err != nil {
+		if deleteErr := s3.DeleteFileFromS3(objectKey); deleteErr != nil {
+			log.Printf("⚠️ Error removing copied object %s: %v", objectKey, deleteErr)
+		}
+		return nil, err
+	}
+	return version, nil
+}
+
+func restoreBlobVersion(file *models.File, old *models.FileVersion) (*models.FileVersion, error) {
+	db := database.GetDB()
+	if err := retainBlob(db, *old.BlobID); err != nil {
+		return nil, err
+	}
+
+	restoredFrom := old.Version
+	version, err := AddVersion(file, models.FileVersion{
+		ObjectKey:        old.ObjectKey,
+		SHA256:           old.SHA256,
+		Corrupted:        old.Corrupted,
+		BlobID:           old.BlobID,
+		FileName:         old.FileName,
+		FileType:         old.FileType,
+		MimeType:         old.MimeType,
+		TypeMismatch:     old.TypeMismatch,
+		Size:             old.Size,
+		ImageWidth:       old.ImageWidth,
+		ImageHeight:      old.ImageHeight,
+		CapturedAt:       old.CapturedAt,
+		MetadataStripped: old.MetadataStripped,
+		RestoredFrom:     &restoredFrom,
+	})
+	if err != nil {
+		if releaseErr := ReleaseBlob(db, *old.BlobID); releaseErr != nil {
+			log.Printf("⚠️ Error releasing blob %s: %v", *old.BlobID, releaseErr)
+		}
+		return nil, err
+	}
+	return version, nil
+}
+
+// SetVersionRetention stores a user's version retention policy. An empty mode
+// returns the user to the server default.
+func SetVersionRetention(user *models.User, mode string, value int) error {
+	switch mode {
+	case "":
+		value = 0
+	case models.VersionRetentionCount:
+		if value < 1 || value > 1000 {
+			return ErrInvalidRetention
+		}
+	case models.VersionRetentionDays:
+		if value < 1 || value > 3650 {
+			return ErrInvalidRetention
+		}
+	default:
+		return ErrInvalidRetention
+	}
+
+	err := database.GetDB().Model(user).Updates(map[string]interface{}{
+		"version_retention_mode":  mode,
+		"version_retention_value": value,
+	}).Error
+	if err != nil {
+		return fmt.Errorf("failed to update version retention: %v", err)
+	}
+	user.VersionRetentionMode = mode
+	user.VersionRetentionValue = value
+	return nil
+}
+
+// EnforceVersionRetention deletes old versions that fall outside their
+// owner's retention policy. Versions that own their object delete it first;
+// blob backed versions release their reference. The current version is
+// always kept, and so are versions whose upload has not finished, since their
+// object is still being written.
+func EnforceVersionRetention() {
+	db := database.GetDB()
+
+	var expired []models.FileVersion
+	err := db.Raw(`
+		SELECT v.id, v.file_id, v.version, v.object_key, v.sha256, v.blob_id, v.file_name, v.file_type, v.size, v.restored_from, v.created_at
+		FROM (
+			SELECT file_versions.*,
+				ROW_NUMBER() OVER (PARTITION BY file_id ORDER BY version DESC) AS newest
+			FROM file_versions
+		) v
+		JOIN files ON files.id = v.file_id
+		JOIN users ON users.id = files.owner_id
+		WHERE v.version <> files.current_version AND NOT v.pending AND (
+			(COALESCE(users.version_retention_mode, '') IN ('', 'count') AND
+				v.newest > COALESCE(NULLIF(users.version_retention_value, 0), ?)) OR
+			(users.version_retention_mode = 'days' AND
+				v.created_at < NOW() - make_interval(days => users.version_retention_value))
+		)
+		LIMIT ?`, config.AppConfig.VersionRetentionCount, retentionBatchSize).
+		Scan(&expired).Error
+	if err != nil {
+		log.Printf("❌ Error finding expired versions: %v", err)
+		return
+	}
+
+	removed := 0
+	for _, version := range expired {
+		if version.BlobID == nil {
+			if err := s3.DeleteFileFromS3(version.ObjectKey); err != nil {
+				log.Printf("❌ Error deleting object of version %d of file %s: %v", version.Version, version.FileID, err)
+				continue
+			}
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Delete(&version).Error; err != nil {
+				return err
+			}
+			if version.BlobID != nil {
+				if err := ReleaseBlob(tx, *version.BlobID); err != nil {
+					return err
+				}
+			}
+			return releaseFileStorage(tx, version.FileID, version.Size)
+		})
+		if err != nil {
+			log.Printf("❌ Error deleting version %d of file %s: %v", version.Version, version.FileID, err)
+			continue
+		}
+		removed++
+	}
+
+	if removed > 0 {
+		log.Printf("🗑️ Version retention removed %d old versions", removed)
+	}
+}
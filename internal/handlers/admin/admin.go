@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/souvik150/file-sharing-app/internal/audit"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
+// recordAdminAction writes an audit entry attributed to the calling admin.
+func recordAdminAction(c *gin.Context, action, targetType, targetID string, details map[string]interface{}) {
+	actorID := uuid.MustParse(c.GetString("userID"))
+	audit.Record(audit.Entry{
+		ActorID:    &actorID,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		IPAddress:  c.ClientIP(),
+		Details:    details,
+	})
+}
+
+// pagination reads the limit and offset query parameters, falling back to
+// sane defaults for missing or out of range values.
+func pagination(c *gin.Context) (int, int) {
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit <= 0 {
+		limit = defaultPageSize
+	}
+	if limit > maxPageSize {
+		limit = maxPageSize
+	}
+
+	offset, err := strconv.Atoi(c.Query("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	return limit, offset
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/souvik150/file-sharing-app/internal/audit"
+	"github.com/souvik150/file-sharing-app/internal/database"
+	"github.com/souvik150/file-sharing-app/internal/models"
+	"github.com/souvik150/file-sharing-app/internal/schemas"
+)
+
+func ListAuditLogsHandler(c *gin.Context) {
+	limit, offset := pagination(c)
+
+	query := database.GetDB().Model(&models.AuditLog{})
+	if action := c.Query("action"); action != "" {
+		query = query.Where("action = ?", action)
+	}
+	if value := c.Query("actor_id"); value != "" {
+		actorID, err := uuid.Parse(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  false,
+				"message": "Invalid actor ID",
+				"error":   err.Error(),
+			})
+			return
+		}
+		query = query.Where("actor_id = ?", actorID)
+	}
+	if targetID := c.Query("target_id"); targetID != "" {
+		query = query.Where("target_id = ?", targetID)
+	}
+	if since := c.Query("since"); since != "" {
+		sinceTime, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  false,
+				"message": "Invalid since timestamp. Expected RFC 3339",
+				"error":   err.Error(),
+			})
+			return
+		}
+		query = query.Where("created_at >= ?", sinceTime)
+	}
+
+	var total int64
+	var entries []models.AuditLog
+	err := query.Count(&total).Error
+	if err == nil {
+		err = query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&entries).Error
+	}
+	if err != nil {
+		log.Printf("Error fetching audit log: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  false,
+			"message": "Failed to fetch audit log",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	entriesResponse := []schemas.AuditLogResponse{}
+	for _, entry := range entries {
+		response := schemas.AuditLogResponse{
+			ID:         entry.ID,
+			ActorID:    entry.ActorID,
+			Action:     entry.Action,
+			TargetType: entry.TargetType,
+			TargetID:   entry.TargetID,
+			IPAddress:  entry.IPAddress,
+			CreatedAt:  entry.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		}
+		if entry.Details != "" {
+			response.Details = json.RawMessage(entry.Details)
+		}
+		entriesResponse = append(entriesResponse, response)
+	}
+
+	recordAdminAction(c, audit.ActionAuditLogView, "audit_log", "", map[string]interface{}{
+		"query": c.Request.URL.RawQuery,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "Audit log fetched successfully",
+		"data": gin.H{
+			"entries": entriesResponse,
+			"total":   total,
+			"limit":   limit,
+			"offset":  offset,
+		},
+	})
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/souvik150/file-sharing-app/internal/audit"
+	"github.com/souvik150/file-sharing-app/internal/database"
+	"github.com/souvik150/file-sharing-app/internal/files"
+	"github.com/souvik150/file-sharing-app/internal/models"
+	"github.com/souvik150/file-sharing-app/internal/schemas"
+)
+
+func GetFileHandler(c *gin.Context) {
+	file, ok := loadTargetFile(c)
+	if !ok {
+		return
+	}
+
+	var activeLinks int64
+	err := database.GetDB().Model(&models.SharedLink{}).
+		Where("file_id = ? AND expires_at > ?", file.ID, time.Now()).
+		Count(&activeLinks).Error
+	if err != nil {
+		log.Printf("Error counting share links: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  false,
+			"message": "Failed to fetch file",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	recordAdminAction(c, audit.ActionFileView, "file", file.ID.String(), map[string]interface{}{
+		"owner_id": file.OwnerID,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "File fetched successfully",
+		"data": schemas.AdminFileResponse{
+			ID:               file.ID,
+			FileName:         file.FileName,
+			Size:             file.Size,
+			FileType:         file.FileType,
+			OwnerID:          file.OwnerID,
+			OwnerEmail:       file.Owner.Email,
+			CreatedAt:        file.CreatedAt.Format("2006-01-02T15:04:05Z"),
+			UpdatedAt:        file.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+			AccessedAt:       file.AccessedAt.Format("2006-01-02T15:04:05Z"),
+			DeletedStatus:    file.DeletedStatus,
+			ActiveShareLinks: activeLinks,
+		},
+	})
+}
+
+func ForceDeleteFileHandler(c *gin.Context) {
+	file, ok := loadTargetFile(c)
+	if !ok {
+		return
+	}
+
+	if err := files.Purge(&file); err != nil {
+		log.Printf("Error force deleting file: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  false,
+			"message": "Failed to delete file",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	recordAdminAction(c, audit.ActionFileForceDelete, "file", file.ID.String(), map[string]interface{}{
+		"owner_id":  file.OwnerID,
+		"file_name": file.FileName,
+		"size":      file.Size,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "File permanently deleted",
+	})
+}
+
+// loadTargetFile writes the error response itself and reports whether the
+// caller may continue.
+func loadTargetFile(c *gin.Context) (models.File, bool) {
+	var file models.File
+
+	fileID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": "Invalid file ID",
+			"error":   err.Error(),
+		})
+		return file, false
+	}
+
+	if err := database.GetDB().Unscoped().Preload("Owner").Where("id = ?", fileID).First(&file).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  false,
+			"message": "File not found",
+			"error":   err.Error(),
+		})
+		return file, false
+	}
+
+	return file, true
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/souvik150/file-sharing-app/internal/audit"
+	"github.com/souvik150/file-sharing-app/internal/database"
+	"github.com/souvik150/file-sharing-app/internal/models"
+	"github.com/souvik150/file-sharing-app/internal/schemas"
+)
+
+func StorageUsageHandler(c *gin.Context) {
+	limit, _ := pagination(c)
+	db := database.GetDB()
+
+	// Files in the trash still occupy storage until they are purged.
+	var usage schemas.StorageUsageResponse
+	err := db.Unscoped().Model(&models.File{}).
+		Select("COUNT(*) AS total_files, COALESCE(SUM(size), 0) AS total_bytes").
+		Scan(&usage).Error
+	if err == nil {
+		err = db.Unscoped().Model(&models.File{}).
+			Select("files.owner_id AS user_id, users.email, COUNT(*) AS file_count, SUM(files.size) AS bytes").
+			Joins("JOIN users ON users.id = files.owner_id").
+			Group("files.owner_id, users.email").
+			Order("bytes DESC").
+			Limit(limit).
+			Scan(&usage.TopUsers).Error
+	}
+	if err == nil {
+		err = db.Unscoped().Model(&models.File{}).
+			Select("file_type, COUNT(*) AS file_count, SUM(size) AS bytes").
+			Group("file_type").
+			Order("bytes DESC").
+			Scan(&usage.ByType).Error
+	}
+	if err != nil {
+		log.Printf("Error computing storage usage: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  false,
+			"message": "Failed to compute storage usage",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	recordAdminAction(c, audit.ActionStorageView, "storage", "", nil)
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "Storage usage fetched successfully",
+		"data":    usage,
+	})
+}
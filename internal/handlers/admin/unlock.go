@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/souvik150/file-sharing-app/internal/audit"
+	"github.com/souvik150/file-sharing-app/internal/auth"
+	"github.com/souvik150/file-sharing-app/internal/schemas"
+)
+
+func UnlockLoginHandler(c *gin.Context) {
+	var input schemas.UnlockLoginInput
+	if err := c.BindJSON(&input); err != nil || (input.Email == "" && input.IPAddress == "") {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": "Invalid request payload",
+			"error":   "email or ip_address is required",
+		})
+		return
+	}
+
+	if err := auth.UnlockLogin(input.Email, input.IPAddress); err != nil {
+		log.Printf("Error unlocking login: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  false,
+			"message": "Failed to unlock login",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	recordAdminAction(c, audit.ActionLoginUnlock, "login", input.Email, map[string]interface{}{
+		"email":      input.Email,
+		"ip_address": input.IPAddress,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "Login unlocked successfully",
+	})
+}
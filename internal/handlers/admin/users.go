@@ -0,0 +1,299 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/souvik150/file-sharing-app/internal/audit"
+	"github.com/souvik150/file-sharing-app/internal/auth"
+	"github.com/souvik150/file-sharing-app/internal/database"
+	"github.com/souvik150/file-sharing-app/internal/files"
+	"github.com/souvik150/file-sharing-app/internal/models"
+	"github.com/souvik150/file-sharing-app/internal/schemas"
+)
+
+type userWithUsage struct {
+	models.User
+	FileCount int64
+}
+
+func ListUsersHandler(c *gin.Context) {
+	limit, offset := pagination(c)
+
+	query := database.GetDB().Model(&models.User{})
+	if email := c.Query("email"); email != "" {
+		query = query.Where("users.email ILIKE ?", "%"+email+"%")
+	}
+	if role := c.Query("role"); role != "" {
+		query = query.Where("users.role = ?", role)
+	}
+	switch c.Query("disabled") {
+	case "true":
+		query = query.Where("users.disabled_at IS NOT NULL")
+	case "false":
+		query = query.Where("users.disabled_at IS NULL")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		log.Printf("Error counting users: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  false,
+			"message": "Failed to fetch users",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	var users []userWithUsage
+	err := query.
+		Select("users.*, COUNT(files.id) AS file_count").
+		Joins("LEFT JOIN files ON files.owner_id = users.id").
+		Group("users.id").
+		Order("users.created_at").
+		Limit(limit).
+		Offset(offset).
+		Scan(&users).Error
+	if err != nil {
+		log.Printf("Error fetching users: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  false,
+			"message": "Failed to fetch users",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	usersResponse := []schemas.AdminUserResponse{}
+	for _, user := range users {
+		usersResponse = append(usersResponse, adminUserResponse(user.User, user.FileCount))
+	}
+
+	recordAdminAction(c, audit.ActionUsersList, "user", "", map[string]interface{}{
+		"query":  c.Request.URL.RawQuery,
+		"result": len(usersResponse),
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "Users fetched successfully",
+		"data": gin.H{
+			"users":  usersResponse,
+			"total":  total,
+			"limit":  limit,
+			"offset": offset,
+		},
+	})
+}
+
+func UpdateUserRoleHandler(c *gin.Context) {
+	user, ok := loadTargetUser(c)
+	if !ok {
+		return
+	}
+
+	var input schemas.UpdateUserRoleInput
+	if err := c.BindJSON(&input); err != nil || !slices.Contains(models.Roles, input.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": "Invalid request payload",
+			"error":   "role must be one of user, admin or auditor",
+		})
+		return
+	}
+
+	if user.ID.String() == c.GetString("userID") {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": "You cannot change your own role",
+		})
+		return
+	}
+
+	previousRole := user.Role
+	if err := database.GetDB().Model(&user).Update("role", input.Role).Error; err != nil {
+		log.Printf("Error updating role: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  false,
+			"message": "Failed to update role",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	recordAdminAction(c, audit.ActionUserRoleChange, "user", user.ID.String(), map[string]interface{}{
+		"from": previousRole,
+		"to":   input.Role,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "Role updated successfully",
+		"data":    adminUserResponse(user, 0),
+	})
+}
+
+func DisableUserHandler(c *gin.Context) {
+	user, ok := loadTargetUser(c)
+	if !ok {
+		return
+	}
+
+	if user.ID.String() == c.GetString("userID") {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": "You cannot disable your own account",
+		})
+		return
+	}
+
+	revoked, err := auth.DisableUser(user.ID)
+	if err != nil {
+		log.Printf("Error disabling user: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  false,
+			"message": "Failed to disable user",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	recordAdminAction(c, audit.ActionUserDisable, "user", user.ID.String(), map[string]interface{}{
+		"email":            user.Email,
+		"revoked_sessions": revoked,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "User disabled successfully",
+		"data":    gin.H{"revoked_sessions": revoked},
+	})
+}
+
+func EnableUserHandler(c *gin.Context) {
+	user, ok := loadTargetUser(c)
+	if !ok {
+		return
+	}
+
+	if err := auth.EnableUser(user.ID); err != nil {
+		log.Printf("Error enabling user: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  false,
+			"message": "Failed to enable user",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	recordAdminAction(c, audit.ActionUserEnable, "user", user.ID.String(), map[string]interface{}{
+		"email": user.Email,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "User enabled successfully",
+	})
+}
+
+func UpdateUserQuotaHandler(c *gin.Context) {
+	user, ok := loadTargetUser(c)
+	if !ok {
+		return
+	}
+
+	var input schemas.UpdateUserQuotaInput
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": "Invalid request payload",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	previousQuota := files.Quota(&user)
+	err := files.SetQuota(&user, input.QuotaBytes)
+	if errors.Is(err, files.ErrInvalidQuota) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": "Invalid quota",
+			"error":   err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Error updating quota: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  false,
+			"message": "Failed to update quota",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	recordAdminAction(c, audit.ActionUserQuotaChange, "user", user.ID.String(), map[string]interface{}{
+		"from":    previousQuota,
+		"to":      files.Quota(&user),
+		"default": input.QuotaBytes == nil,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "Quota updated successfully",
+		"data":    adminUserResponse(user, 0),
+	})
+}
+
+// loadTargetUser writes the error response itself and reports whether the
+// caller may continue.
+func loadTargetUser(c *gin.Context) (models.User, bool) {
+	var user models.User
+
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": "Invalid user ID",
+			"error":   err.Error(),
+		})
+		return user, false
+	}
+
+	if err := database.GetDB().Where("id = ?", userID).First(&user).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  false,
+			"message": "User not found",
+			"error":   err.Error(),
+		})
+		return user, false
+	}
+
+	return user, true
+}
+
+func adminUserResponse(user models.User, fileCount int64) schemas.AdminUserResponse {
+	response := schemas.AdminUserResponse{
+		ID:               user.ID,
+		Email:            user.Email,
+		Role:             user.Role,
+		EmailVerified:    user.EmailVerifiedAt != nil,
+		TwoFactorEnabled: user.TOTPEnabledAt != nil,
+		Disabled:         user.DisabledAt != nil,
+		FileCount:        fileCount,
+		StorageUsed:      user.StorageUsed,
+		StorageReserved:  user.StorageReserved,
+		StorageQuota:     files.Quota(&user),
+		CustomQuota:      user.StorageQuota != nil,
+		CreatedAt:        user.CreatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+	if user.DisabledAt != nil {
+		response.DisabledAt = user.DisabledAt.Format("2006-01-02T15:04:05Z")
+	}
+	return response
+}
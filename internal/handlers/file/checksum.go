@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"mime/multipart"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/souvik150/file-sharing-app/internal/files"
+)
+
+// checksumHeader carries the SHA-256 of file content. Clients may set it on
+// each part of an upload, or on the request when it holds a single file, and
+// downloads return it in hex.
+const checksumHeader = "X-Checksum-SHA256"
+
+var errInvalidChecksum = errors.New("X-Checksum-SHA256 must be a SHA-256 digest in hex or base64")
+var errChecksumMismatch = errors.New("content does not match X-Checksum-SHA256")
+
+// parseChecksum accepts a SHA-256 digest in hex or base64 and returns it in
+// lowercase hex. An empty value means no checksum was supplied.
+func parseChecksum(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil
+	}
+	if len(value) == hex.EncodedLen(sha256.Size) {
+		if _, err := hex.DecodeString(value); err == nil {
+			return strings.ToLower(value), nil
+		}
+	}
+	digest, err := base64.StdEncoding.DecodeString(value)
+	if err != nil || len(digest) != sha256.Size {
+		return "", errInvalidChecksum
+	}
+	return hex.EncodeToString(digest), nil
+}
+
+// uploadChecksum is the checksum supplied for one uploaded file: the header on
+// its own part, or the request's when the request carries only that file.
+func uploadChecksum(c *gin.Context, header *multipart.FileHeader, single bool) (string, error) {
+	value := header.Header.Get(checksumHeader)
+	if value == "" && single {
+		value = c.GetHeader(checksumHeader)
+	}
+	return parseChecksum(value)
+}
+
+// verifyDownload checks downloaded content against the stored checksum and
+// flags the object as corrupted when it does not match.
+func verifyDownload(objectKey, expected string, data []byte) bool {
+	if expected == "" || checksumOf(data) == expected {
+		return true
+	}
+	log.Printf("🚨 Object %s does not match its stored checksum", objectKey)
+	if err := files.RecordVerification(objectKey, "", true); err != nil {
+		log.Printf("Error flagging object %s as corrupted: %v", objectKey, err)
+	}
+	return false
+}
+
+func checksumOf(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -1,16 +1,20 @@
 package handlers
 
 import (
+	"errors"
 	"log"
 	"net/http"
-	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 
 	"github.com/souvik150/file-sharing-app/internal/database"
+	"github.com/souvik150/file-sharing-app/internal/files"
 	"github.com/souvik150/file-sharing-app/internal/models"
+	"github.com/souvik150/file-sharing-app/internal/schemas"
 )
 
+// DeleteFileHandler moves a file to the trash.
 func DeleteFileHandler(c *gin.Context) {
 	fileId := c.Param("id")
 	if fileId == "" {
@@ -21,27 +25,30 @@ func DeleteFileHandler(c *gin.Context) {
 	dbClient := database.GetDB()
 	var file models.File
 
-	if err := dbClient.Where("id = ?", fileId).First(&file).Error; err != nil {
+	if err := dbClient.Where("id = ? AND owner_id = ?", fileId, c.GetString("userID")).First(&file).Error; err != nil {
 		log.Printf("Error retrieving file from database: %v", err)
 		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
 		return
 	}
 
-	file.DeletedAt = time.Now()	
-
-	if err := dbClient.Save(&file).Error; err != nil {
-		log.Printf("Error marking file as deleted: %v", err)
+	if err := files.Trash(&file); err != nil {
+		log.Printf("Error moving file to trash: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete file"})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "File deleted successfully",
-		"data":    file.FileName + " deleted",
+		"message": "File moved to trash",
+		"data": gin.H{
+			"id":       file.ID,
+			"purge_at": files.PurgeAt(&file).Format("2006-01-02T15:04:05Z"),
+		},
 	})
 }
 
+// GetUserDeletedFilesHandler lists the files in the trash, most recently
+// deleted first.
 func GetUserDeletedFilesHandler(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
@@ -55,7 +62,7 @@ func GetUserDeletedFilesHandler(c *gin.Context) {
 	dbClient := database.GetDB()
 	var deletedFiles []models.File
 
-	if err := dbClient.Where("deleted_at IS NOT NULL AND owner_id = ?", userID).Find(&deletedFiles).Error; err != nil {
+	if err := dbClient.Unscoped().Where("deleted_at IS NOT NULL AND owner_id = ?", userID).Order("deleted_at DESC").Find(&deletedFiles).Error; err != nil {
 		log.Printf("Error retrieving user deleted files: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to get deleted files",
@@ -63,5 +70,135 @@ func GetUserDeletedFilesHandler(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"deletedFiles": deletedFiles})
+	response := []schemas.TrashedFileResponse{}
+	for i := range deletedFiles {
+		response = append(response, trashedFileResponse(&deletedFiles[i]))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Deleted files fetched successfully",
+		"data":    response,
+	})
+}
+
+// loadTrashedFile writes the error response itself and reports whether the
+// caller may continue.
+func loadTrashedFile(c *gin.Context) (*models.File, bool) {
+	fileID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": "Invalid file ID",
+			"error":   err.Error(),
+		})
+		return nil, false
+	}
+
+	file, err := files.GetTrashedFile(uuid.MustParse(c.GetString("userID")), fileID)
+	switch {
+	case errors.Is(err, files.ErrFileNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  false,
+			"message": "File not found",
+			"error":   err.Error(),
+		})
+		return nil, false
+	case errors.Is(err, files.ErrNotInTrash):
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  false,
+			"message": "File is not in the trash",
+			"error":   err.Error(),
+		})
+		return nil, false
+	case err != nil:
+		log.Printf("Error loading trashed file: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  false,
+			"message": "Failed to load file",
+			"error":   err.Error(),
+		})
+		return nil, false
+	}
+	return file, true
+}
+
+func RestoreFileHandler(c *gin.Context) {
+	file, ok := loadTrashedFile(c)
+	if !ok {
+		return
+	}
+
+	if err := files.Restore(file); err != nil {
+		log.Printf("Error restoring file %s: %v", file.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  false,
+			"message": "Failed to restore file",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "File restored successfully",
+		"data":    gin.H{"id": file.ID, "folder_id": file.FolderID},
+	})
+}
+
+// PermanentlyDeleteFileHandler removes a trashed file, its object and its
+// share links for good.
+func PermanentlyDeleteFileHandler(c *gin.Context) {
+	file, ok := loadTrashedFile(c)
+	if !ok {
+		return
+	}
+
+	if err := files.Purge(file); err != nil {
+		log.Printf("Error purging file %s: %v", file.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  false,
+			"message": "Failed to delete file",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "File permanently deleted",
+	})
+}
+
+func EmptyTrashHandler(c *gin.Context) {
+	count, bytes, err := files.EmptyTrash(uuid.MustParse(c.GetString("userID")))
+	if err != nil {
+		log.Printf("Error emptying trash: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  false,
+			"message": "Failed to empty trash",
+			"error":   err.Error(),
+			"data":    gin.H{"files_deleted": count, "bytes_deleted": bytes},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "Trash emptied successfully",
+		"data":    gin.H{"files_deleted": count, "bytes_deleted": bytes},
+	})
+}
+
+func trashedFileResponse(file *models.File) schemas.TrashedFileResponse {
+	return schemas.TrashedFileResponse{
+		ID:        file.ID,
+		FileName:  file.FileName,
+		Size:      file.Size,
+		FileType:  file.FileType,
+		FolderID:  file.FolderID,
+		CreatedAt: file.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		DeletedAt: file.DeletedAt.Time.Format("2006-01-02T15:04:05Z"),
+		PurgeAt:   files.PurgeAt(file).Format("2006-01-02T15:04:05Z"),
+	}
 }
@@ -0,0 +1,285 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/souvik150/file-sharing-app/internal/database"
+	"github.com/souvik150/file-sharing-app/internal/files"
+	"github.com/souvik150/file-sharing-app/internal/models"
+	"github.com/souvik150/file-sharing-app/internal/schemas"
+)
+
+// respondFolderError maps errors from the files package to responses.
+func respondFolderError(c *gin.Context, err error, action string) {
+	switch {
+	case errors.Is(err, files.ErrFolderNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  false,
+			"message": "Folder not found",
+			"error":   err.Error(),
+		})
+	case errors.Is(err, files.ErrFolderNameTaken):
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  false,
+			"message": "A folder with this name already exists here",
+			"error":   err.Error(),
+		})
+	case errors.Is(err, files.ErrInvalidFolderName), errors.Is(err, files.ErrFolderCycle):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": "Invalid folder operation",
+			"error":   err.Error(),
+		})
+	default:
+		log.Printf("Error trying to %s: %v", action, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  false,
+			"message": "Failed to " + action,
+			"error":   err.Error(),
+		})
+	}
+}
+
+// loadOwnFolder writes the error response itself and reports whether the
+// caller may continue.
+func loadOwnFolder(c *gin.Context) (*models.Folder, bool) {
+	folderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": "Invalid folder ID",
+			"error":   err.Error(),
+		})
+		return nil, false
+	}
+
+	folder, err := files.GetFolder(uuid.MustParse(c.GetString("userID")), folderID)
+	if err != nil {
+		respondFolderError(c, err, "load folder")
+		return nil, false
+	}
+	return folder, true
+}
+
+func CreateFolderHandler(c *gin.Context) {
+	var input schemas.CreateFolderInput
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": "Invalid request payload",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	folder, err := files.CreateFolder(uuid.MustParse(c.GetString("userID")), input.ParentID, input.Name)
+	if err != nil {
+		respondFolderError(c, err, "create folder")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  true,
+		"message": "Folder created successfully",
+		"data":    folderResponse(folder),
+	})
+}
+
+// GetRootFolderHandler lists the folders and files at the top of the tree.
+func GetRootFolderHandler(c *gin.Context) {
+	respondFolderContents(c, nil)
+}
+
+func GetFolderHandler(c *gin.Context) {
+	folder, ok := loadOwnFolder(c)
+	if !ok {
+		return
+	}
+	respondFolderContents(c, folder)
+}
+
+func respondFolderContents(c *gin.Context, folder *models.Folder) {
+	ownerID := uuid.MustParse(c.GetString("userID"))
+	db := database.GetDB()
+
+	contents := schemas.FolderContentsResponse{
+		Path:    []schemas.BreadcrumbResponse{},
+		Folders: []schemas.FolderResponse{},
+		Files:   []schemas.FileResponse{},
+	}
+
+	folderQuery := db.Where("owner_id = ?", ownerID)
+	fileQuery := db.Where("owner_id = ?", ownerID)
+	if folder == nil {
+		folderQuery = folderQuery.Where("parent_id IS NULL")
+		fileQuery = fileQuery.Where("folder_id IS NULL")
+	} else {
+		response := folderResponse(folder)
+		contents.Folder = &response
+
+		path, err := files.FolderPath(ownerID, folder.ID)
+		if err != nil {
+			respondFolderError(c, err, "resolve folder path")
+			return
+		}
+		for _, ancestor := range path {
+			contents.Path = append(contents.Path, schemas.BreadcrumbResponse{ID: ancestor.ID, Name: ancestor.Name})
+		}
+
+		folderQuery = folderQuery.Where("parent_id = ?", folder.ID)
+		fileQuery = fileQuery.Where("folder_id = ?", folder.ID)
+	}
+
+	var subfolders []models.Folder
+	if err := folderQuery.Order("LOWER(name)").Find(&subfolders).Error; err != nil {
+		respondFolderError(c, err, "list folders")
+		return
+	}
+	for i := range subfolders {
+		contents.Folders = append(contents.Folders, folderResponse(&subfolders[i]))
+	}
+
+	var folderFiles []models.File
+	if err := fileQuery.Preload("Tags").Preload("Metadata").Preload("Preview").Order("LOWER(file_name)").Find(&folderFiles).Error; err != nil {
+		respondFolderError(c, err, "list files")
+		return
+	}
+	for _, file := range folderFiles {
+		contents.Files = append(contents.Files, fileResponse(file))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "Folder fetched successfully",
+		"data":    contents,
+	})
+}
+
+func RenameFolderHandler(c *gin.Context) {
+	folder, ok := loadOwnFolder(c)
+	if !ok {
+		return
+	}
+
+	var input schemas.RenameFolderInput
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": "Invalid request payload",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if err := files.RenameFolder(folder, input.Name); err != nil {
+		respondFolderError(c, err, "rename folder")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "Folder renamed successfully",
+		"data":    folderResponse(folder),
+	})
+}
+
+func MoveFolderHandler(c *gin.Context) {
+	folder, ok := loadOwnFolder(c)
+	if !ok {
+		return
+	}
+
+	var input schemas.MoveFolderInput
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": "Invalid request payload",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if err := files.MoveFolder(folder, input.ParentID); err != nil {
+		respondFolderError(c, err, "move folder")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "Folder moved successfully",
+		"data":    folderResponse(folder),
+	})
+}
+
+func DeleteFolderHandler(c *gin.Context) {
+	folder, ok := loadOwnFolder(c)
+	if !ok {
+		return
+	}
+
+	foldersDeleted, filesDeleted, err := files.DeleteFolder(folder)
+	if err != nil {
+		respondFolderError(c, err, "delete folder")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "Folder deleted successfully",
+		"data": gin.H{
+			"folders_deleted": foldersDeleted,
+			"files_deleted":   filesDeleted,
+		},
+	})
+}
+
+func MoveFileHandler(c *gin.Context) {
+	var input schemas.MoveFileInput
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": "Invalid request payload",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	var file models.File
+	err := database.GetDB().
+		Where("id = ? AND owner_id = ?", c.Param("id"), c.GetString("userID")).
+		First(&file).Error
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  false,
+			"message": "File not found",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if err := files.MoveFile(&file, input.FolderID); err != nil {
+		respondFolderError(c, err, "move file")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "File moved successfully",
+		"data":    gin.H{"id": file.ID, "folder_id": input.FolderID},
+	})
+}
+
+func folderResponse(folder *models.Folder) schemas.FolderResponse {
+	return schemas.FolderResponse{
+		ID:        folder.ID,
+		Name:      folder.Name,
+		ParentID:  folder.ParentID,
+		CreatedAt: folder.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		UpdatedAt: folder.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+}
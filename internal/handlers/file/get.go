@@ -2,20 +2,32 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 
 	"github.com/souvik150/file-sharing-app/internal/cache"
 	"github.com/souvik150/file-sharing-app/internal/database"
+	"github.com/souvik150/file-sharing-app/internal/files"
 	"github.com/souvik150/file-sharing-app/internal/models"
 	"github.com/souvik150/file-sharing-app/internal/schemas"
+	"github.com/souvik150/file-sharing-app/internal/search"
 	"github.com/souvik150/file-sharing-app/pkg/s3"
 )
 
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
 func GetUserFilesHandler(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
@@ -37,10 +49,52 @@ func GetUserFilesHandler(c *gin.Context) {
 
 	// Get search filters
 	fileName := c.Query("name")
-	fileType := c.Query("type")	
 	uploadDate := c.Query("uploadDate")
+	folder := c.Query("folder")
+	searchQuery := strings.TrimSpace(c.Query("q"))
+	tagMatch := c.DefaultQuery("tagMatch", "any")
+	metadata := c.QueryMap("meta")
+
+	log.Printf("Searching files for user %s with filters: name=%s, type=%v, uploadDate=%s, tags=%v (%s), meta=%v, q=%s", parsedUserID.String(), fileName, c.QueryArray("type"), uploadDate, c.QueryArray("tag"), tagMatch, metadata, searchQuery)
+
+	if tagMatch != "any" && tagMatch != "all" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid tagMatch. Expected any or all.",
+		})
+		return
+	}
 
-	log.Printf("Searching files for user %s with filters: name=%s, type=%s, uploadDate=%s", parsedUserID.String(), fileName, fileType, uploadDate)
+	var tags []string
+	for _, value := range c.QueryArray("tag") {
+		for _, tag := range strings.Split(value, ",") {
+			normalized, err := files.NormalizeTag(tag)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": err.Error(),
+				})
+				return
+			}
+			tags = append(tags, normalized)
+		}
+	}
+
+	var fileTypes []string
+	for _, value := range c.QueryArray("type") {
+		for _, fileType := range strings.Split(value, ",") {
+			if fileType = strings.ToLower(strings.TrimSpace(fileType)); fileType != "" {
+				fileTypes = append(fileTypes, fileType)
+			}
+		}
+	}
+
+	for key := range metadata {
+		if _, err := files.NormalizeMetadataKey(key); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+	}
 
 	var parsedDate time.Time
 	if uploadDate != "" {
@@ -54,57 +108,157 @@ func GetUserFilesHandler(c *gin.Context) {
 		}
 	}
 
+	// Date ranges take a day or a full timestamp; a bare day as the upper
+	// bound includes that whole day.
+	createdFrom, err := parseDateBound(c.Query("createdFrom"), false)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid createdFrom. Expected YYYY-MM-DD or an RFC 3339 timestamp.",
+		})
+		return
+	}
+	createdTo, err := parseDateBound(c.Query("createdTo"), true)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid createdTo. Expected YYYY-MM-DD or an RFC 3339 timestamp.",
+		})
+		return
+	}
+
+	minSize, err := parseSizeBound(c.Query("minSize"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid minSize. Expected a number of bytes.",
+		})
+		return
+	}
+	maxSize, err := parseSizeBound(c.Query("maxSize"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid maxSize. Expected a number of bytes.",
+		})
+		return
+	}
+
+	pageRequest, err := parsePageRequest(c, searchQuery)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
 	db := database.GetDB()
-	var files []models.File
 
-	query := db.Where("owner_id = ?", parsedUserID)
+	query := db.Model(&models.File{}).
+		Where("owner_id = ?", parsedUserID).
+		Scopes(files.WithTags(tags, tagMatch == "all"), files.WithMetadata(metadata))
 
 	if fileName != "" {
 		query = query.Where("file_name ILIKE ?", "%"+fileName+"%")
 	}
-	if fileType != "" {
-		query = query.Where("file_type = ?", fileType)
+	if len(fileTypes) > 0 {
+		query = query.Where("LOWER(file_type) IN ?", fileTypes)
 	}
 	if !parsedDate.IsZero() {
 		query = query.Where("DATE(created_at) = ?", parsedDate)
 	}
+	if !createdFrom.IsZero() {
+		query = query.Where("created_at >= ?", createdFrom)
+	}
+	if !createdTo.IsZero() {
+		query = query.Where("created_at < ?", createdTo)
+	}
+	if minSize != nil {
+		query = query.Where("size >= ?", *minSize)
+	}
+	if maxSize != nil {
+		query = query.Where("size <= ?", *maxSize)
+	}
+	if searchQuery != "" {
+		query = query.Scopes(search.Matching(searchQuery))
+	}
+	if folder == "root" {
+		query = query.Where("folder_id IS NULL")
+	} else if folder != "" {
+		folderID, err := uuid.Parse(folder)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid folder. Expected a folder ID or root.",
+			})
+			return
+		}
+		query = query.Where("folder_id = ?", folderID)
+	}
+
+	// The filtered query is shared by the count and the page.
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		log.Printf("Error counting files: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve files",
+		})
+		return
+	}
 
-	if err := query.Find(&files).Error; err != nil {
+	page, err := files.Paginate(query.Preload("Tags").Preload("Metadata").Preload("Preview"), pageRequest)
+	if errors.Is(err, files.ErrInvalidCursor) || errors.Is(err, files.ErrInvalidSort) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err != nil {
 		log.Printf("Error fetching files: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to retrieve files",
 		})
 		return
 	}
+	userFiles := page.Files
+
+	var matches map[uuid.UUID]search.Match
+	if searchQuery != "" {
+		fileIDs := make([]uuid.UUID, 0, len(userFiles))
+		for _, file := range userFiles {
+			fileIDs = append(fileIDs, file.ID)
+		}
+		matches, err = search.Highlight(fileIDs, searchQuery)
+		if err != nil {
+			log.Printf("Error highlighting search results: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to retrieve files",
+			})
+			return
+		}
+	}
 
 	userResponse := schemas.FilesResponse{}
 
 	redisClient := cache.GetClient()
-	for _, file := range files {
+	for _, file := range userFiles {
 		cacheKey := file.ID.String()
 
 		cachedData, err := redisClient.Get(cache.Ctx, cacheKey).Result()
 		if err == nil && cachedData != "" {
 			var cachedFile schemas.FileCache
 			if err := json.Unmarshal([]byte(cachedData), &cachedFile); err == nil {
-				fileResponse := schemas.FileResponse{
-					ID:            cachedFile.ID,
-					FileName:      cachedFile.FileName,
-					Size:          cachedFile.Size,
-					FileType:      cachedFile.FileType,
-					CreatedAt:     file.CreatedAt.Format("2006-01-02T15:04:05Z"),
-					UpdatedAt:     file.UpdatedAt.Format("2006-01-02T15:04:05Z"),
-					AccessedAt:    file.AccessedAt.Format("2006-01-02T15:04:05Z"),
-					DeletedStatus: file.DeletedStatus,
-				}
-				userResponse.Files = append(userResponse.Files, fileResponse)
+				response := fileResponse(file)
+				response.ID = cachedFile.ID
+				response.FileName = cachedFile.FileName
+				response.Size = cachedFile.Size
+				response.FileType = cachedFile.FileType
+				response.Rank = matches[file.ID].Rank
+				response.Snippet = matches[file.ID].Snippet
+				userResponse.Files = append(userResponse.Files, response)
 				log.Printf("File %s (ID: %s) loaded from cache", file.FileName, file.ID.String())
 				continue
 			}
 		}
 
-		s3ObjectName := file.ID.String() + "." + file.FileType
-		link, err := s3.GeneratePresignedURL(s3ObjectName)
+		link, err := s3.GeneratePresignedURL(file.StorageKey())
 		if err != nil {
 			log.Printf("Error generating presigned URL: %v", err)
 			c.JSON(http.StatusInternalServerError, gin.H{
@@ -113,17 +267,10 @@ func GetUserFilesHandler(c *gin.Context) {
 			return
 		}
 
-		fileResponse := schemas.FileResponse{
-			ID:            file.ID,
-			FileName:      file.FileName,
-			Size:          file.Size,
-			FileType:      file.FileType,
-			CreatedAt:     file.CreatedAt.Format("2006-01-02T15:04:05Z"),
-			UpdatedAt:     file.UpdatedAt.Format("2006-01-02T15:04:05Z"),
-			AccessedAt:    file.AccessedAt.Format("2006-01-02T15:04:05Z"),
-			DeletedStatus: file.DeletedStatus,
-		}
-		userResponse.Files = append(userResponse.Files, fileResponse)
+		response := fileResponse(file)
+		response.Rank = matches[file.ID].Rank
+		response.Snippet = matches[file.ID].Snippet
+		userResponse.Files = append(userResponse.Files, response)
 
 		fileCache := schemas.FileCache{
 			ID:       file.ID,
@@ -146,9 +293,123 @@ func GetUserFilesHandler(c *gin.Context) {
 		log.Printf("File %s (ID: %s) loaded from database", file.FileName, file.ID.String())
 	}
 
+	if userResponse.Files == nil {
+		userResponse.Files = []schemas.FileResponse{}
+	}
+
 	c.JSON(http.StatusOK , gin.H{
 		"success": true,
 		"message": "Files fetched successfully",
 		"data": userResponse.Files,
+		"pagination": schemas.PageInfo{
+			Limit:      pageRequest.Limit,
+			Total:      total,
+			NextCursor: page.NextCursor,
+			PrevCursor: page.PrevCursor,
+		},
 	})
 }
+
+// parsePageRequest reads limit, cursor, sort and order. Listings default to
+// the newest files first, or the best matches first when searching.
+func parsePageRequest(c *gin.Context, searchQuery string) (files.PageRequest, error) {
+	request := files.PageRequest{
+		Sort:       c.Query("sort"),
+		Descending: true,
+		Limit:      defaultPageSize,
+		Cursor:     c.Query("cursor"),
+		Search:     searchQuery,
+	}
+
+	if request.Sort == "" {
+		request.Sort = files.SortCreated
+		if searchQuery != "" {
+			request.Sort = files.SortRelevance
+		}
+	}
+
+	switch c.Query("order") {
+	case "", "desc":
+	case "asc":
+		request.Descending = false
+	default:
+		return request, errors.New("Invalid order. Expected asc or desc.")
+	}
+
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxPageSize {
+			return request, fmt.Errorf("Invalid limit. Expected a number between 1 and %d.", maxPageSize)
+		}
+		request.Limit = limit
+	}
+
+	return request, nil
+}
+
+func parseDateBound(value string, upper bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if day, err := time.Parse("2006-01-02", value); err == nil {
+		if upper {
+			return day.AddDate(0, 0, 1), nil
+		}
+		return day, nil
+	}
+	bound, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if upper {
+		// The bound is exclusive in the query, so include the instant itself.
+		return bound.Add(time.Nanosecond), nil
+	}
+	return bound, nil
+}
+
+func parseSizeBound(value string) (*int64, error) {
+	if value == "" {
+		return nil, nil
+	}
+	size, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || size < 0 {
+		return nil, errors.New("invalid size")
+	}
+	return &size, nil
+}
+
+// fileResponse describes a file for listings. Its Tags, Metadata and Preview
+// should be loaded.
+func fileResponse(file models.File) schemas.FileResponse {
+	return schemas.FileResponse{
+		ID:               file.ID,
+		FileName:         file.FileName,
+		Size:             file.Size,
+		FileType:         file.FileType,
+		MimeType:         file.MimeType,
+		TypeMismatch:     file.TypeMismatch,
+		ImageWidth:       file.ImageWidth,
+		ImageHeight:      file.ImageHeight,
+		CapturedAt:       formatOptionalTime(file.CapturedAt),
+		MetadataStripped: file.MetadataStripped,
+		SHA256:           file.SHA256,
+		Corrupted:        file.Corrupted,
+		ThumbnailURLs:    files.ThumbnailURLs(&file),
+		FolderID:         file.FolderID,
+		Tags:             files.TagNames(&file),
+		Metadata:         files.MetadataMap(&file),
+		CreatedAt:        file.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		UpdatedAt:        file.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+		AccessedAt:       file.AccessedAt.Format("2006-01-02T15:04:05Z"),
+		DeletedStatus:    file.DeletedStatus,
+	}
+}
+
+// formatOptionalTime formats a timestamp that may be unset as empty.
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format("2006-01-02T15:04:05Z")
+}
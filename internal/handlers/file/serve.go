@@ -31,12 +31,24 @@ func ServeSharedFileHandler(c *gin.Context) {
 		return
 	}
 
-	fileData, err := s3.DownloadFile(sharedLink.FileID.String())
+	// Links to files in the trash stop working until the file is restored.
+	var file models.File
+	if err := dbClient.Select("id", "object_key", "sha256").Where("id = ?", sharedLink.FileID).First(&file).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+
+	fileData, err := s3.DownloadFile(file.StorageKey())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve file"})
 		return
 	}
+	if !verifyDownload(file.StorageKey(), file.SHA256, fileData) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "File failed integrity verification"})
+		return
+	}
 
+	c.Header(checksumHeader, checksumOf(fileData))
 	c.Header("Content-Disposition", "attachment; filename="+sharedLink.FileName)
 	c.Data(http.StatusOK, "application/octet-stream", fileData)
 }
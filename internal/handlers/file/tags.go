@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/souvik150/file-sharing-app/internal/files"
+	"github.com/souvik150/file-sharing-app/internal/schemas"
+)
+
+// respondAttributeError maps tag and metadata errors from the files package to
+// responses.
+func respondAttributeError(c *gin.Context, err error, action string) {
+	switch {
+	case errors.Is(err, files.ErrFilesNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  false,
+			"message": "File not found",
+			"error":   err.Error(),
+		})
+	case errors.Is(err, files.ErrInvalidTag), errors.Is(err, files.ErrInvalidMetadataKey),
+		errors.Is(err, files.ErrInvalidMetadataValue), errors.Is(err, files.ErrTooManyTags),
+		errors.Is(err, files.ErrTooManyMetadata):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": "Invalid request payload",
+			"error":   err.Error(),
+		})
+	default:
+		log.Printf("Error trying to %s: %v", action, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  false,
+			"message": "Failed to " + action,
+			"error":   err.Error(),
+		})
+	}
+}
+
+func UpdateTagsHandler(c *gin.Context) {
+	var input schemas.UpdateTagsInput
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": "Invalid request payload",
+			"error":   err.Error(),
+		})
+		return
+	}
+	if len(input.Add) == 0 && len(input.Remove) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": "Invalid request payload",
+			"error":   "add or remove must list at least one tag",
+		})
+		return
+	}
+
+	if err := files.UpdateTags(uuid.MustParse(c.GetString("userID")), input.FileIDs, input.Add, input.Remove); err != nil {
+		respondAttributeError(c, err, "update tags")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "Tags updated successfully",
+		"data":    gin.H{"files_updated": len(input.FileIDs)},
+	})
+}
+
+func UpdateMetadataHandler(c *gin.Context) {
+	var input schemas.UpdateMetadataInput
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": "Invalid request payload",
+			"error":   err.Error(),
+		})
+		return
+	}
+	if len(input.Set) == 0 && len(input.Remove) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": "Invalid request payload",
+			"error":   "set or remove must list at least one key",
+		})
+		return
+	}
+
+	if err := files.UpdateMetadata(uuid.MustParse(c.GetString("userID")), input.FileIDs, input.Set, input.Remove); err != nil {
+		respondAttributeError(c, err, "update metadata")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "Metadata updated successfully",
+		"data":    gin.H{"files_updated": len(input.FileIDs)},
+	})
+}
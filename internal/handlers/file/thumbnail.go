@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/souvik150/file-sharing-app/internal/files"
+)
+
+// defaultThumbnailSize is served when the request does not ask for a size.
+const defaultThumbnailSize = 256
+
+// GetThumbnailHandler serves a thumbnail of a file's current version. The
+// requested size is rounded up to the nearest generated one.
+func GetThumbnailHandler(c *gin.Context) {
+	file, ok := loadOwnFile(c)
+	if !ok {
+		return
+	}
+
+	requested := defaultThumbnailSize
+	if value := c.Query("size"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  false,
+				"message": "Invalid size",
+				"error":   "size must be a positive number of pixels",
+			})
+			return
+		}
+		requested = parsed
+	}
+	size := files.ThumbnailSize(requested)
+
+	// Thumbnails only change with the file's content, which bumps the version.
+	etag := fmt.Sprintf(`"%s-%d-%d"`, file.ID, file.CurrentVersion, size)
+	if c.GetHeader("If-None-Match") == etag {
+		c.Header("Cache-Control", "private, max-age=86400")
+		c.Header("ETag", etag)
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	data, preview, err := files.Thumbnail(file, size)
+	if errors.Is(err, files.ErrThumbnailNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  false,
+			"message": "Thumbnail not available",
+			"error":   err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Error loading thumbnail of file %s: %v", file.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  false,
+			"message": "Failed to retrieve thumbnail",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.Header("Cache-Control", "private, max-age=86400")
+	c.Header("ETag", etag)
+	c.Data(http.StatusOK, "image/"+preview.Format, data)
+}
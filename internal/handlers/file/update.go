@@ -1,15 +1,18 @@
 package handlers
 
 import (
+	"log"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 
 	"github.com/souvik150/file-sharing-app/internal/cache"
 	"github.com/souvik150/file-sharing-app/internal/database"
 	"github.com/souvik150/file-sharing-app/internal/models"
 	"github.com/souvik150/file-sharing-app/internal/schemas"
+	"github.com/souvik150/file-sharing-app/internal/search"
 )
 
 func UpdateFileHandler(c *gin.Context) {
@@ -48,6 +51,10 @@ func UpdateFileHandler(c *gin.Context) {
 			return
 	}
 
+	if err := search.Refresh(dbClient, []uuid.UUID{file.ID}); err != nil {
+			log.Printf("Error re-indexing renamed file %s: %v", file.ID, err)
+	}
+
 	// delete from cache
 	cacheClient := cache.GetClient()
 	if err := cacheClient.Del(cache.Ctx, fileId).Err(); err != nil {
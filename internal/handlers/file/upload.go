@@ -1,25 +1,66 @@
 package handlers
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"io"
 	"log"
 	"mime/multipart"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 
+	"github.com/souvik150/file-sharing-app/internal/config"
 	"github.com/souvik150/file-sharing-app/internal/database"
+	"github.com/souvik150/file-sharing-app/internal/files"
 	"github.com/souvik150/file-sharing-app/internal/models"
 	"github.com/souvik150/file-sharing-app/internal/schemas"
+	"github.com/souvik150/file-sharing-app/internal/search"
+	"github.com/souvik150/file-sharing-app/internal/socket"
 	"github.com/souvik150/file-sharing-app/pkg/s3"
 )
 
 func UploadMultipleFilesHandler(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get userID from context",
+		})
+		return
+	}
+
+	parsedUserID, err := uuid.Parse(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to parse userID",
+		})
+		return
+	}
+
+	fits, err := requestFitsQuota(c, parsedUserID)
+	if err != nil {
+		log.Printf("Error checking quota of user %s: %v", parsedUserID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to check storage quota",
+		})
+		return
+	}
+	if !fits {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error": "Storage quota exceeded",
+		})
+		return
+	}
+
 	form, err := c.MultipartForm()
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -28,128 +69,555 @@ func UploadMultipleFilesHandler(c *gin.Context) {
 		return
 	}
 
-	files := form.File["files"]
-	if len(files) == 0 {
+	fileHeaders := form.File["files"]
+	if len(fileHeaders) == 0 {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "No files were uploaded",
 		})
 		return
 	}
 
-	userID, exists := c.Get("userID")
-	if !exists {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to get userID from context",
-		})
-		return
+	var folderID *uuid.UUID
+	if value := c.PostForm("folder_id"); value != "" {
+		parsedFolderID, err := uuid.Parse(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid folder_id",
+			})
+			return
+		}
+		if _, err := files.GetFolder(parsedUserID, parsedFolderID); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Folder not found",
+			})
+			return
+		}
+		folderID = &parsedFolderID
 	}
 
-	parsedUserID, err := uuid.Parse(userID.(string))
+	strip, err := stripMetadata(c, parsedUserID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to parse userID",
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
 		})
 		return
 	}
 
-	db := database.GetDB()
+	results := make([]schemas.UploadResult, len(fileHeaders))
+	stored := make([]*pendingUpload, len(fileHeaders))
 
-	var uploadedFiles []string
-	var uploadFilesPaths []string
-	var mu sync.Mutex
+	// Files are checked and saved locally by a fixed number of workers, and
+	// each records its outcome in its own slot so results keep request order.
+	jobs := make(chan int)
 	var wg sync.WaitGroup
-	wg.Add(len(files))
-
-	for _, header := range files {
-		go func(header *multipart.FileHeader) {
+	for w := 0; w < uploadWorkers(len(fileHeaders)); w++ {
+		wg.Add(1)
+		go func() {
 			defer wg.Done()
+			for i := range jobs {
+				header := fileHeaders[i]
+				results[i] = schemas.UploadResult{FileName: header.Filename}
 
-			file, err := header.Open()
-			if err != nil {
-				log.Printf("Error opening file: %v", err)
-				return
-			}
-			defer file.Close()
-
-			fileExt := filepath.Ext(header.Filename)
-			if len(fileExt) > 0 {
-				fileExt = fileExt[1:]
-			} else {
-				log.Printf("File %s has no extension", header.Filename)
-			}
-
-			newFile := models.File{
-				ID:         uuid.New(),
-				FileName:   header.Filename,
-				OwnerID:    parsedUserID,
-				Size:       header.Size,
-				FileType:   fileExt,
-				CreatedAt:  time.Now(),
-				AccessedAt: time.Now(),
-				UpdatedAt:  time.Now(),
-				DeletedStatus: false,
-				DeletedAt: time.Time{},
-			}
-
-			err = db.Create(&newFile).Error
-			if err != nil {
-				log.Printf("Error creating file in database: %v", err)
-				return
-			}
-
-			tmpDir := "/local"
-			if _, err := os.Stat(tmpDir); os.IsNotExist(err) {
-				log.Printf("Creating /local directory...")
-				err = os.Mkdir(tmpDir, 0755)
+				upload, err := checkUpload(c, header, len(fileHeaders) == 1)
+				if err == nil {
+					stored[i], err = storeReserved(parsedUserID, header.Size, func() (*pendingUpload, error) {
+						return storeUpload(upload, parsedUserID, folderID, strip)
+					})
+				}
 				if err != nil {
-					log.Printf("Error creating /local directory: %v", err)
-					return
+					log.Printf("Upload of %s not stored: %v", header.Filename, err)
+					results[i].Status, results[i].ErrorCode = uploadErrorStatus(err)
+					results[i].Error = err.Error()
+					continue
 				}
-			}
-
-			filePath := filepath.Join(tmpDir, newFile.ID.String())
-			log.Printf("Saving file locally at path: %s", filePath)
-
-			out, err := os.Create(filePath)
-			if err != nil {
-				log.Printf("Error creating local file: %v", err)
-				return
-			}
-			defer out.Close()
 
-			_, err = io.Copy(out, file)
-			if err != nil {
-				log.Printf("Error writing file to local storage: %v", err)
-				return
+				fileID := stored[i].fileID
+				results[i].FileID = &fileID
+				results[i].Status = schemas.UploadStatusAccepted
 			}
-
-			mu.Lock()
-			uploadedFiles = append(uploadedFiles, newFile.FileName)
-			uploadFilesPaths = append(uploadFilesPaths, filePath)
-			mu.Unlock()
-
-			log.Printf("File %s stored locally at %s", header.Filename, filePath)
-		}(header)
+		}()
 	}
-
+	for i := range fileHeaders {
+		jobs <- i
+	}
+	close(jobs)
 	wg.Wait()
 
-	go s3.ProcessFilesAsync(uploadFilesPaths, parsedUserID.String())
+	uploadedFiles := []string{}
+	var pending []*pendingUpload
+	for _, upload := range stored {
+		if upload != nil {
+			uploadedFiles = append(uploadedFiles, upload.fileName)
+			pending = append(pending, upload)
+		}
+	}
+
+	if len(pending) > 0 {
+		go finishUploads(parsedUserID, pending)
+	}
 
 	var res schemas.UploadedFileResponse
 	res.FileNames = uploadedFiles
+	res.Results = results
+
+	// All stored is a plain success; a mix of outcomes is reported per file
+	// with 207 Multi-Status.
+	rejected, failed := 0, 0
+	for _, result := range results {
+		switch result.Status {
+		case schemas.UploadStatusRejected:
+			rejected++
+		case schemas.UploadStatusFailed:
+			failed++
+		}
+	}
 
-	if len(uploadedFiles) > 0 {
+	switch {
+	case rejected == 0 && failed == 0:
 		c.JSON(http.StatusOK, gin.H{
 			"success": true,
 			"message": "Files uploaded. Undergoing processing",
-			"data": res,
+			"data":    res,
 		})
-	} else {
+	case len(uploadedFiles) > 0:
+		c.JSON(http.StatusMultiStatus, gin.H{
+			"success": false,
+			"message": "Some files were not uploaded",
+			"data":    res,
+		})
+	case failed == 0:
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"success": false,
+			"message": "No files were accepted",
+			"data":    res,
+		})
+	default:
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"message": "Failed to upload files",
-			"error": "Failed to store files locally",
+			"data":    res,
 		})
 	}
 }
+
+var errStoreFailed = errors.New("failed to store file")
+
+// notifyUploadFailed tells the owner that a file accepted for processing
+// could not be stored after all.
+func notifyUploadFailed(ownerID, fileID uuid.UUID, fileName string) {
+	message, _ := json.Marshal(map[string]interface{}{
+		"type":      "upload_failed",
+		"file_id":   fileID,
+		"file_name": fileName,
+	})
+	socket.NotifyUser(ownerID.String(), string(message))
+}
+
+// uploadErrorStatus classifies why a file was not stored: rejected files
+// broke a rule and would fail again as sent, failed ones may be retried.
+func uploadErrorStatus(err error) (string, string) {
+	switch {
+	case errors.Is(err, errInvalidChecksum):
+		return schemas.UploadStatusRejected, "invalid_checksum"
+	case errors.Is(err, errChecksumMismatch):
+		return schemas.UploadStatusRejected, "checksum_mismatch"
+	case errors.Is(err, files.ErrFileTooLarge):
+		return schemas.UploadStatusRejected, "file_too_large"
+	case errors.Is(err, files.ErrFileTypeNotAllowed):
+		return schemas.UploadStatusRejected, "type_not_allowed"
+	case errors.Is(err, files.ErrFileTypeMismatch):
+		return schemas.UploadStatusRejected, "type_mismatch"
+	case errors.Is(err, files.ErrQuotaExceeded):
+		return schemas.UploadStatusRejected, "quota_exceeded"
+	case errors.Is(err, files.ErrInvalidImage):
+		return schemas.UploadStatusRejected, "invalid_image"
+	}
+	return schemas.UploadStatusFailed, "storage_failed"
+}
+
+var errInvalidStripMetadata = errors.New("strip_metadata must be true or false")
+
+// stripMetadata decides whether metadata is stripped from uploaded images:
+// as the strip_metadata form field says, or by the owner's setting.
+func stripMetadata(c *gin.Context, ownerID uuid.UUID) (bool, error) {
+	if value := c.PostForm("strip_metadata"); value != "" {
+		strip, err := strconv.ParseBool(value)
+		if err != nil {
+			return false, errInvalidStripMetadata
+		}
+		return strip, nil
+	}
+
+	var owner models.User
+	if err := database.GetDB().Select("strip_image_metadata").Where("id = ?", ownerID).First(&owner).Error; err != nil {
+		log.Printf("Error loading image metadata setting: %v", err)
+	}
+	return owner.StripImageMetadata, nil
+}
+
+// uploadWorkers is how many files of a request are processed at once.
+func uploadWorkers(count int) int {
+	workers := config.AppConfig.UploadWorkers
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > count {
+		workers = count
+	}
+	return workers
+}
+
+// multipartOverhead allows for the form fields and part headers around the
+// file content when a request's length is compared with the quota.
+const multipartOverhead = 64 << 10
+
+// requestFitsQuota judges by Content-Length, before the body is read, whether
+// the uploaded content could fit in the owner's remaining quota. Requests of
+// unknown length are left to the reservation made for each file.
+func requestFitsQuota(c *gin.Context, ownerID uuid.UUID) (bool, error) {
+	length := c.Request.ContentLength - multipartOverhead
+	if length <= 0 {
+		return true, nil
+	}
+	return files.FitsQuota(ownerID, length)
+}
+
+// acceptedUpload is a file that passed the checks made before it is stored.
+type acceptedUpload struct {
+	header   *multipart.FileHeader
+	checksum string
+	detected *files.DetectedType
+}
+
+// checkUpload validates the checksum supplied for a file, sniffs its type and
+// applies the upload policy to it.
+func checkUpload(c *gin.Context, header *multipart.FileHeader, single bool) (*acceptedUpload, error) {
+	checksum, err := uploadChecksum(c, header, single)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return nil, errors.New("failed to read file")
+	}
+	defer file.Close()
+
+	detected, err := files.DetectType(file, header.Filename)
+	if err != nil {
+		return nil, err
+	}
+	if err := files.CheckUploadPolicy(header.Size, detected); err != nil {
+		return nil, err
+	}
+	return &acceptedUpload{header: header, checksum: checksum, detected: detected}, nil
+}
+
+// savedUpload is an accepted file saved locally under its object key, with
+// the version it becomes once uploaded.
+type savedUpload struct {
+	path    string
+	version models.FileVersion
+}
+
+// saveUpload saves an accepted file locally, verifying its checksum on the
+// way, and strips image metadata when asked.
+func saveUpload(upload *acceptedUpload, objectKey string, strip bool) (*savedUpload, error) {
+	header, detected := upload.header, upload.detected
+
+	file, err := header.Open()
+	if err != nil {
+		log.Printf("Error opening file: %v", err)
+		return nil, errStoreFailed
+	}
+	defer file.Close()
+
+	fileExt := filepath.Ext(header.Filename)
+	if len(fileExt) > 0 {
+		fileExt = fileExt[1:]
+	} else {
+		log.Printf("File %s has no extension, detected %s", header.Filename, detected.MimeType)
+		fileExt = detected.Extension
+	}
+
+	tmpDir := "/local"
+	if _, err := os.Stat(tmpDir); os.IsNotExist(err) {
+		log.Printf("Creating /local directory...")
+		err = os.Mkdir(tmpDir, 0755)
+		if err != nil && !os.IsExist(err) {
+			log.Printf("Error creating /local directory: %v", err)
+			return nil, errStoreFailed
+		}
+	}
+
+	filePath := filepath.Join(tmpDir, objectKey)
+	log.Printf("Saving file locally at path: %s", filePath)
+
+	out, err := os.Create(filePath)
+	if err != nil {
+		log.Printf("Error creating local file: %v", err)
+		return nil, errStoreFailed
+	}
+
+	// The file is hashed as it is saved so a damaged upload is rejected
+	// before anything is recorded.
+	hash := sha256.New()
+	_, err = io.Copy(io.MultiWriter(out, hash), file)
+	out.Close()
+	if err != nil {
+		log.Printf("Error writing file to local storage: %v", err)
+		os.Remove(filePath)
+		return nil, errStoreFailed
+	}
+	if upload.checksum != "" && hex.EncodeToString(hash.Sum(nil)) != upload.checksum {
+		os.Remove(filePath)
+		return nil, errChecksumMismatch
+	}
+
+	version := models.FileVersion{
+		ObjectKey:    objectKey,
+		FileName:     header.Filename,
+		FileType:     fileExt,
+		MimeType:     detected.MimeType,
+		TypeMismatch: detected.Mismatch,
+		Size:         header.Size,
+	}
+
+	image, err := prepareImage(filePath, detected.MimeType, strip)
+	if err != nil {
+		os.Remove(filePath)
+		return nil, err
+	}
+	if image != nil {
+		version.Size = image.size
+		version.ImageWidth = image.Width
+		version.ImageHeight = image.Height
+		version.CapturedAt = image.CapturedAt
+		version.MetadataStripped = image.Stripped
+	}
+
+	return &savedUpload{path: filePath, version: version}, nil
+}
+
+// pendingUpload is a file saved locally and recorded, waiting to go to S3.
+// record stores its uploaded content and abandon undoes what was recorded
+// when the upload fails.
+type pendingUpload struct {
+	path     string
+	fileID   uuid.UUID
+	fileName string
+	size     int64
+	record   func(blob *models.Blob) error
+	abandon  func()
+}
+
+// storeReserved reserves an upload's declared size while store saves it. Only
+// the stored size stays reserved, since stripping image metadata may leave a
+// file smaller than declared.
+func storeReserved(ownerID uuid.UUID, size int64, store func() (*pendingUpload, error)) (*pendingUpload, error) {
+	if err := files.ReserveStorage(ownerID, size); err != nil {
+		return nil, err
+	}
+
+	pending, err := store()
+	if err != nil {
+		files.ReleaseReservation(ownerID, size)
+		return nil, err
+	}
+	if freed := size - pending.size; freed > 0 {
+		files.ReleaseReservation(ownerID, freed)
+	}
+	return pending, nil
+}
+
+// storeUpload saves an accepted file locally and records it as a new file
+// with its first version, which stays pending until its content is uploaded.
+func storeUpload(upload *acceptedUpload, ownerID uuid.UUID, folderID *uuid.UUID, strip bool) (*pendingUpload, error) {
+	fileID := uuid.New()
+	saved, err := saveUpload(upload, fileID.String(), strip)
+	if err != nil {
+		return nil, err
+	}
+
+	version := saved.version
+	newFile := models.File{
+		ID:               fileID,
+		FileName:         version.FileName,
+		OwnerID:          ownerID,
+		FolderID:         folderID,
+		Size:             version.Size,
+		FileType:         version.FileType,
+		MimeType:         version.MimeType,
+		TypeMismatch:     version.TypeMismatch,
+		ImageWidth:       version.ImageWidth,
+		ImageHeight:      version.ImageHeight,
+		CapturedAt:       version.CapturedAt,
+		MetadataStripped: version.MetadataStripped,
+		ObjectKey:        version.ObjectKey,
+		CurrentVersion:   1,
+		CreatedAt:        time.Now(),
+		AccessedAt:       time.Now(),
+		UpdatedAt:        time.Now(),
+		DeletedStatus:    false,
+	}
+	version.ID = uuid.New()
+	version.FileID = fileID
+	version.Version = 1
+	version.Pending = true
+
+	db := database.GetDB()
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&newFile).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&version).Error; err != nil {
+			return err
+		}
+		return files.SettleReservation(tx, ownerID, newFile.Size)
+	})
+	if err != nil {
+		log.Printf("Error creating file in database: %v", err)
+		os.Remove(saved.path)
+		return nil, errStoreFailed
+	}
+
+	if err := search.Refresh(db, []uuid.UUID{newFile.ID}); err != nil {
+		log.Printf("Error indexing file name: %v", err)
+	}
+
+	log.Printf("File %s stored locally at %s", newFile.FileName, saved.path)
+	return &pendingUpload{
+		path:     saved.path,
+		fileID:   newFile.ID,
+		fileName: newFile.FileName,
+		size:     newFile.Size,
+		record: func(blob *models.Blob) error {
+			return files.LinkVersionBlob(newFile.ID, 1, blob)
+		},
+		abandon: func() {
+			if err := files.Purge(&newFile); err != nil {
+				log.Printf("❌ Error removing failed upload %s: %v", newFile.ID, err)
+			}
+		},
+	}, nil
+}
+
+// storeVersion saves an accepted file locally as the next version of an
+// existing file. The version is only recorded once its content is uploaded,
+// and its reservation is released if that fails.
+func storeVersion(upload *acceptedUpload, file models.File, strip bool) (*pendingUpload, error) {
+	saved, err := saveUpload(upload, uuid.New().String(), strip)
+	if err != nil {
+		return nil, err
+	}
+
+	version := saved.version
+	return &pendingUpload{
+		path:     saved.path,
+		fileID:   file.ID,
+		fileName: version.FileName,
+		size:     version.Size,
+		record: func(blob *models.Blob) error {
+			version.BlobID = &blob.ID
+			version.SHA256 = blob.SHA256
+			version.ObjectKey = blob.ObjectKey
+			created, err := files.AddVersion(&file, version)
+			if err != nil {
+				return err
+			}
+			log.Printf("✅ File %s is now at version %d", file.ID, created.Version)
+			return nil
+		},
+		abandon: func() {
+			files.ReleaseReservation(file.OwnerID, version.Size)
+		},
+	}, nil
+}
+
+// finishUploads sends locally saved files to S3 and records their content,
+// sharing what the owner already stores. The files were already reported as
+// accepted, so one that fails is abandoned and its owner told.
+func finishUploads(ownerID uuid.UUID, pending []*pendingUpload) {
+	byKey := make(map[string]*pendingUpload, len(pending))
+	paths := make([]string, 0, len(pending))
+	for _, upload := range pending {
+		byKey[filepath.Base(upload.path)] = upload
+		paths = append(paths, upload.path)
+	}
+
+	dedup := files.NewDeduplicator(ownerID)
+	uploads, failures := s3.ProcessFilesAsync(paths, dedup.Skip)
+
+	var recorded []string
+	for _, upload := range uploads {
+		entry, ok := byKey[upload.Key]
+		if !ok {
+			continue
+		}
+		blob, err := dedup.Store(upload)
+		if err != nil {
+			log.Printf("❌ Error recording content of file %s: %v", entry.fileID, err)
+			failures = append(failures, s3.FailedUpload{Key: upload.Key, Err: err})
+			continue
+		}
+		if err := entry.record(blob); err != nil {
+			log.Printf("❌ Error linking content of file %s: %v", entry.fileID, err)
+			if err := files.ReleaseBlob(database.GetDB(), blob.ID); err != nil {
+				log.Printf("⚠️ Error releasing blob %s: %v", blob.ID, err)
+			}
+			failures = append(failures, s3.FailedUpload{Key: upload.Key, Err: err})
+			continue
+		}
+		recorded = append(recorded, entry.fileID.String())
+	}
+
+	for _, failure := range failures {
+		entry, ok := byKey[failure.Key]
+		if !ok {
+			continue
+		}
+		entry.abandon()
+		notifyUploadFailed(ownerID, entry.fileID, entry.fileName)
+	}
+	if len(failures) == 0 {
+		socket.NotifyUser(ownerID.String(), "All files processed and uploaded to S3.")
+	}
+
+	search.IndexFiles(recorded)
+	files.GenerateThumbnailsFor(recorded)
+}
+
+// preparedImage is the metadata kept from an image saved locally, and the
+// image's size once stripped.
+type preparedImage struct {
+	*files.ImageMetadata
+	size int64
+}
+
+// prepareImage reads the metadata kept from a locally saved image and strips
+// the rest when asked. An image that cannot be stripped is rejected; when
+// nothing is stripped, failing to read it only loses the metadata.
+func prepareImage(path, mimeType string, strip bool) (*preparedImage, error) {
+	image, err := files.PrepareImage(path, mimeType, strip)
+	if err != nil && !strip {
+		log.Printf("Could not read metadata of image %s: %v", path, err)
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("Error stripping metadata of image %s: %v", path, err)
+		if errors.Is(err, files.ErrInvalidImage) {
+			return nil, err
+		}
+		return nil, errStoreFailed
+	}
+	if image == nil {
+		return nil, nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		log.Printf("Error reading size of image %s: %v", path, err)
+		return nil, errStoreFailed
+	}
+	return &preparedImage{ImageMetadata: image, size: info.Size()}, nil
+}
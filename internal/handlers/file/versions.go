@@ -0,0 +1,294 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/souvik150/file-sharing-app/internal/database"
+	"github.com/souvik150/file-sharing-app/internal/files"
+	"github.com/souvik150/file-sharing-app/internal/models"
+	"github.com/souvik150/file-sharing-app/internal/schemas"
+	"github.com/souvik150/file-sharing-app/internal/search"
+	"github.com/souvik150/file-sharing-app/pkg/s3"
+)
+
+// loadOwnFile writes the error response itself and reports whether the
+// caller may continue.
+func loadOwnFile(c *gin.Context) (*models.File, bool) {
+	fileID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": "Invalid file ID",
+			"error":   err.Error(),
+		})
+		return nil, false
+	}
+
+	var file models.File
+	err = database.GetDB().Where("id = ? AND owner_id = ?", fileID, c.GetString("userID")).First(&file).Error
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  false,
+			"message": "File not found",
+			"error":   err.Error(),
+		})
+		return nil, false
+	}
+	return &file, true
+}
+
+// loadVersion writes the error response itself and reports whether the
+// caller may continue.
+func loadVersion(c *gin.Context, file *models.File) (*models.FileVersion, bool) {
+	number, err := strconv.Atoi(c.Param("version"))
+	if err != nil || number < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": "Invalid version",
+			"error":   "version must be a positive number",
+		})
+		return nil, false
+	}
+
+	version, err := files.GetVersion(file.ID, number)
+	if errors.Is(err, files.ErrVersionNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  false,
+			"message": "Version not found",
+			"error":   err.Error(),
+		})
+		return nil, false
+	}
+	if err != nil {
+		log.Printf("Error loading version %d of file %s: %v", number, file.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  false,
+			"message": "Failed to load version",
+			"error":   err.Error(),
+		})
+		return nil, false
+	}
+	return version, true
+}
+
+// UploadVersionHandler stores a new upload as the next version of an existing
+// file. It becomes current once the upload to S3 completes.
+func UploadVersionHandler(c *gin.Context) {
+	file, ok := loadOwnFile(c)
+	if !ok {
+		return
+	}
+
+	fits, err := requestFitsQuota(c, file.OwnerID)
+	if err != nil {
+		log.Printf("Error checking quota of user %s: %v", file.OwnerID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  false,
+			"message": "Failed to check storage quota",
+			"error":   err.Error(),
+		})
+		return
+	}
+	if !fits {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"status":  false,
+			"message": "Storage quota exceeded",
+			"error":   files.ErrQuotaExceeded.Error(),
+		})
+		return
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": "No file was uploaded",
+			"error":   err.Error(),
+		})
+		return
+	}
+	strip, err := stripMetadata(c, file.OwnerID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": "Invalid strip_metadata",
+			"error":   err.Error(),
+		})
+		return
+	}
+	accepted, err := checkUpload(c, header, true)
+	if err != nil {
+		status := http.StatusBadRequest
+		switch {
+		case errors.Is(err, files.ErrFileTooLarge):
+			status = http.StatusRequestEntityTooLarge
+		case errors.Is(err, files.ErrFileTypeNotAllowed), errors.Is(err, files.ErrFileTypeMismatch):
+			status = http.StatusUnsupportedMediaType
+		}
+		c.JSON(status, gin.H{
+			"status":  false,
+			"message": "Upload rejected",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	pending, err := storeReserved(file.OwnerID, header.Size, func() (*pendingUpload, error) {
+		return storeVersion(accepted, *file, strip)
+	})
+	if err != nil {
+		status, message := http.StatusInternalServerError, "Failed to store upload"
+		switch {
+		case errors.Is(err, files.ErrQuotaExceeded):
+			status, message = http.StatusRequestEntityTooLarge, "Storage quota exceeded"
+		case errors.Is(err, errChecksumMismatch):
+			status, message = http.StatusUnprocessableEntity, "Uploaded content does not match the supplied checksum"
+		case errors.Is(err, files.ErrInvalidImage):
+			status, message = http.StatusUnprocessableEntity, "Failed to strip image metadata"
+		default:
+			log.Printf("Error storing new version of file %s: %v", file.ID, err)
+		}
+		c.JSON(status, gin.H{
+			"status":  false,
+			"message": message,
+			"error":   err.Error(),
+		})
+		return
+	}
+	go finishUploads(file.OwnerID, []*pendingUpload{pending})
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"status":  true,
+		"message": "New version uploaded. Undergoing processing",
+		"data":    gin.H{"id": file.ID, "file_name": header.Filename},
+	})
+}
+
+func ListVersionsHandler(c *gin.Context) {
+	file, ok := loadOwnFile(c)
+	if !ok {
+		return
+	}
+
+	versions, err := files.ListVersions(file.ID)
+	if err != nil {
+		log.Printf("Error listing versions of file %s: %v", file.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  false,
+			"message": "Failed to list versions",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	response := []schemas.FileVersionResponse{}
+	for i := range versions {
+		response = append(response, versionResponse(file, &versions[i]))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "Versions fetched successfully",
+		"data":    response,
+	})
+}
+
+func DownloadVersionHandler(c *gin.Context) {
+	file, ok := loadOwnFile(c)
+	if !ok {
+		return
+	}
+	version, ok := loadVersion(c, file)
+	if !ok {
+		return
+	}
+
+	data, err := s3.DownloadFile(version.ObjectKey)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve file"})
+		return
+	}
+	if !verifyDownload(version.ObjectKey, version.SHA256, data) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "File failed integrity verification"})
+		return
+	}
+
+	c.Header(checksumHeader, checksumOf(data))
+	c.Header("Content-Disposition", "attachment; filename="+version.FileName)
+	c.Data(http.StatusOK, "application/octet-stream", data)
+}
+
+// RestoreVersionHandler makes an old version current again as a new version.
+func RestoreVersionHandler(c *gin.Context) {
+	file, ok := loadOwnFile(c)
+	if !ok {
+		return
+	}
+	old, ok := loadVersion(c, file)
+	if !ok {
+		return
+	}
+	if old.Version == file.CurrentVersion {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  false,
+			"message": "Version is already current",
+			"error":   "version is already current",
+		})
+		return
+	}
+
+	restored, err := files.RestoreVersion(file, old)
+	if errors.Is(err, files.ErrQuotaExceeded) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"status":  false,
+			"message": "Storage quota exceeded",
+			"error":   err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Error restoring version %d of file %s: %v", old.Version, file.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  false,
+			"message": "Failed to restore version",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if err := search.IndexFile(file); err != nil {
+		log.Printf("Error indexing file %s: %v", file.ID, err)
+	}
+	go func(file models.File) {
+		if err := files.GenerateThumbnails(&file); err != nil {
+			log.Printf("❌ Error generating thumbnails of file %s: %v", file.ID, err)
+		}
+	}(*file)
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "Version restored successfully",
+		"data":    versionResponse(file, restored),
+	})
+}
+
+func versionResponse(file *models.File, version *models.FileVersion) schemas.FileVersionResponse {
+	return schemas.FileVersionResponse{
+		Version:      version.Version,
+		FileName:     version.FileName,
+		FileType:     version.FileType,
+		Size:         version.Size,
+		MimeType:     version.MimeType,
+		SHA256:       version.SHA256,
+		Corrupted:    version.Corrupted,
+		Current:      version.Version == file.CurrentVersion,
+		RestoredFrom: version.RestoredFrom,
+		CreatedAt:    version.CreatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+}
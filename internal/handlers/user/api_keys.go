@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/souvik150/file-sharing-app/internal/auth"
+	"github.com/souvik150/file-sharing-app/internal/database"
+	"github.com/souvik150/file-sharing-app/internal/models"
+	"github.com/souvik150/file-sharing-app/internal/schemas"
+)
+
+func apiKeyResponse(apiKey *models.APIKey) schemas.APIKeyResponse {
+	response := schemas.APIKeyResponse{
+		ID:        apiKey.ID,
+		Name:      apiKey.Name,
+		Prefix:    apiKey.Prefix,
+		Scopes:    auth.APIKeyScopes(apiKey),
+		CreatedAt: apiKey.CreatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+	if apiKey.ExpiresAt != nil {
+		response.ExpiresAt = apiKey.ExpiresAt.Format("2006-01-02T15:04:05Z")
+	}
+	if apiKey.LastUsedAt != nil {
+		response.LastUsedAt = apiKey.LastUsedAt.Format("2006-01-02T15:04:05Z")
+	}
+	return response
+}
+
+func CreateAPIKeyHandler(c *gin.Context) {
+	var input schemas.CreateAPIKeyInput
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": "Invalid request payload",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	scopes, err := auth.ValidateScopes(input.Scopes)
+	if errors.Is(err, auth.ErrUnknownScope) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": "Invalid scopes",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	granted := c.MustGet("scopes").([]string)
+	for _, scope := range scopes {
+		if !slices.Contains(granted, scope) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"status":  false,
+				"message": "Cannot grant a scope your session does not have",
+				"error":   "scope not granted: " + scope,
+			})
+			return
+		}
+	}
+
+	if input.ExpiresAt != nil && input.ExpiresAt.Before(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": "Invalid expiry",
+			"error":   "expires_at must be in the future",
+		})
+		return
+	}
+
+	userID := uuid.MustParse(c.GetString("userID"))
+	rawKey, apiKey, err := auth.CreateAPIKey(userID, input.Name, scopes, input.ExpiresAt)
+	if err != nil {
+		log.Printf("Error creating API key: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  false,
+			"message": "Failed to create API key",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  true,
+		"message": "API key created successfully. Store it now, it will not be shown again",
+		"data": schemas.CreatedAPIKeyResponse{
+			APIKeyResponse: apiKeyResponse(apiKey),
+			Key:            rawKey,
+		},
+	})
+}
+
+func ListAPIKeysHandler(c *gin.Context) {
+	var apiKeys []models.APIKey
+	err := database.GetDB().
+		Where("user_id = ? AND revoked_at IS NULL", c.GetString("userID")).
+		Order("created_at DESC").
+		Find(&apiKeys).Error
+	if err != nil {
+		log.Printf("Error fetching API keys: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  false,
+			"message": "Failed to fetch API keys",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	apiKeysResponse := []schemas.APIKeyResponse{}
+	for i := range apiKeys {
+		apiKeysResponse = append(apiKeysResponse, apiKeyResponse(&apiKeys[i]))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "API keys fetched successfully",
+		"data":    apiKeysResponse,
+	})
+}
+
+func RevokeAPIKeyHandler(c *gin.Context) {
+	apiKeyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": "Invalid API key ID",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	result := database.GetDB().Model(&models.APIKey{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", apiKeyID, c.GetString("userID")).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		log.Printf("Error revoking API key: %v", result.Error)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  false,
+			"message": "Failed to revoke API key",
+			"error":   result.Error.Error(),
+		})
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  false,
+			"message": "API key not found",
+			"error":   "no active API key with this ID",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "API key revoked successfully",
+	})
+}
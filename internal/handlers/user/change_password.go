@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/souvik150/file-sharing-app/internal/auth"
+	"github.com/souvik150/file-sharing-app/internal/schemas"
+)
+
+func ChangePasswordHandler(c *gin.Context) {
+	claims := c.MustGet("tokenClaims").(*auth.AccessClaims)
+
+	var input schemas.ChangePasswordInput
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": "Invalid request payload",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	userID := uuid.MustParse(claims.UserID)
+	currentSessionID := uuid.MustParse(claims.FamilyID)
+
+	revoked, err := auth.ChangePassword(userID, currentSessionID, input.CurrentPassword, input.NewPassword)
+	var policyErr *auth.PasswordPolicyError
+	switch {
+	case errors.As(err, &policyErr):
+		respondWeakPassword(c, policyErr)
+		return
+	case errors.Is(err, auth.ErrWrongPassword):
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"status":  false,
+			"message": "Current password is incorrect",
+			"error":   err.Error(),
+		})
+		return
+	case err != nil:
+		log.Printf("Error changing password: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  false,
+			"message": "Failed to change password",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "Password changed successfully. Other sessions have been signed out",
+		"data":    gin.H{"revoked_sessions": revoked},
+	})
+}
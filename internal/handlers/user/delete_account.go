@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/souvik150/file-sharing-app/internal/account"
+	"github.com/souvik150/file-sharing-app/internal/auth"
+	"github.com/souvik150/file-sharing-app/internal/schemas"
+)
+
+func RequestAccountDeletionHandler(c *gin.Context) {
+	var input schemas.DeleteAccountInput
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": "Invalid request payload",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	if err := auth.VerifyPassword(user, input.Password); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"status":  false,
+			"message": "Password is incorrect",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	scheduledAt, err := account.RequestDeletion(user, c.ClientIP())
+	if err != nil {
+		log.Printf("Error scheduling account deletion: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  false,
+			"message": "Failed to schedule account deletion",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"status":  true,
+		"message": "Account scheduled for deletion. Log in and cancel before the date below to keep it",
+		"data":    gin.H{"deletion_scheduled_at": scheduledAt.Format("2006-01-02T15:04:05Z")},
+	})
+}
+
+func CancelAccountDeletionHandler(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	err := account.CancelDeletion(user, c.ClientIP())
+	if errors.Is(err, account.ErrNoDeletionScheduled) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  false,
+			"message": "No account deletion is scheduled",
+			"error":   err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Error cancelling account deletion: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  false,
+			"message": "Failed to cancel account deletion",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "Account deletion cancelled",
+	})
+}
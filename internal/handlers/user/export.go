@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/souvik150/file-sharing-app/internal/database"
+	"github.com/souvik150/file-sharing-app/internal/export"
+	"github.com/souvik150/file-sharing-app/internal/models"
+	"github.com/souvik150/file-sharing-app/internal/schemas"
+)
+
+func RequestExportHandler(c *gin.Context) {
+	userID := uuid.MustParse(c.GetString("userID"))
+
+	dataExport, err := export.Request(userID)
+	if errors.Is(err, export.ErrExportInProgress) {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  false,
+			"message": "An export is already being prepared",
+			"error":   err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Error requesting export: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  false,
+			"message": "Failed to start export",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"status":  true,
+		"message": "Export started. You will be notified over the WebSocket when it is ready",
+		"data":    exportResponse(dataExport),
+	})
+}
+
+func ListExportsHandler(c *gin.Context) {
+	var exports []models.DataExport
+	err := database.GetDB().Where("user_id = ?", c.GetString("userID")).Order("created_at DESC").Find(&exports).Error
+	if err != nil {
+		log.Printf("Error fetching exports: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  false,
+			"message": "Failed to fetch exports",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	exportsResponse := []schemas.DataExportResponse{}
+	for i := range exports {
+		exportsResponse = append(exportsResponse, exportResponse(&exports[i]))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "Exports fetched successfully",
+		"data":    exportsResponse,
+	})
+}
+
+// ExportLinkHandler issues a fresh download link for a ready export, for
+// clients that were not connected when it was announced.
+func ExportLinkHandler(c *gin.Context) {
+	var dataExport models.DataExport
+	err := database.GetDB().Where("id = ? AND user_id = ?", c.Param("id"), c.GetString("userID")).First(&dataExport).Error
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  false,
+			"message": "Export not found",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	link, expiresAt, err := export.IssueDownloadLink(&dataExport)
+	if errors.Is(err, export.ErrExportNotReady) {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  false,
+			"message": "Export is not ready or has expired",
+			"error":   err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Error issuing export link: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  false,
+			"message": "Failed to issue download link",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "Download link issued successfully",
+		"data": schemas.ExportLinkResponse{
+			DownloadURL: link,
+			ExpiresAt:   expiresAt.Format("2006-01-02T15:04:05Z"),
+		},
+	})
+}
+
+// DownloadExportHandler serves an archive to anyone holding its download
+// link, so it works from a plain browser navigation.
+func DownloadExportHandler(c *gin.Context) {
+	dataExport, err := export.ResolveDownload(c.Param("id"), c.Query("token"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  false,
+			"message": "Download link is invalid or expired",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.FileAttachment(dataExport.FilePath, "export-"+dataExport.CreatedAt.Format("2006-01-02")+".zip")
+}
+
+func exportResponse(dataExport *models.DataExport) schemas.DataExportResponse {
+	response := schemas.DataExportResponse{
+		ID:        dataExport.ID,
+		Status:    dataExport.Status,
+		Size:      dataExport.Size,
+		Error:     dataExport.Error,
+		CreatedAt: dataExport.CreatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+	if dataExport.CompletedAt != nil {
+		response.CompletedAt = dataExport.CompletedAt.Format("2006-01-02T15:04:05Z")
+	}
+	if dataExport.ExpiresAt != nil {
+		response.ExpiresAt = dataExport.ExpiresAt.Format("2006-01-02T15:04:05Z")
+	}
+	return response
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/souvik150/file-sharing-app/internal/database"
+	"github.com/souvik150/file-sharing-app/internal/files"
+	"github.com/souvik150/file-sharing-app/internal/models"
+	"github.com/souvik150/file-sharing-app/internal/schemas"
+)
+
+func GetImageMetadataSettingHandler(c *gin.Context) {
+	var user models.User
+	if err := database.GetDB().Where("id = ?", c.GetString("userID")).First(&user).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  false,
+			"message": "User not found",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "Image metadata setting fetched successfully",
+		"data":    schemas.ImageMetadataSettingResponse{Strip: user.StripImageMetadata},
+	})
+}
+
+func UpdateImageMetadataSettingHandler(c *gin.Context) {
+	var input schemas.ImageMetadataSettingInput
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": "Invalid request payload",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	var user models.User
+	if err := database.GetDB().Where("id = ?", c.GetString("userID")).First(&user).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  false,
+			"message": "User not found",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if err := files.SetStripImageMetadata(&user, *input.Strip); err != nil {
+		log.Printf("Error updating image metadata setting: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  false,
+			"message": "Failed to update image metadata setting",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "Image metadata setting updated successfully",
+		"data":    schemas.ImageMetadataSettingResponse{Strip: user.StripImageMetadata},
+	})
+}
@@ -1,21 +1,45 @@
 package handlers
 
 import (
+	"errors"
 	"log"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
 
-	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
 
-	appConfig "github.com/souvik150/file-sharing-app/internal/config"
+	"github.com/souvik150/file-sharing-app/internal/auth"
 	"github.com/souvik150/file-sharing-app/internal/database"
 	"github.com/souvik150/file-sharing-app/internal/models"
 	"github.com/souvik150/file-sharing-app/internal/schemas"
 )
 
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("not-a-real-password"), bcrypt.DefaultCost)
+
+// respondInvalidCredentials is the single answer for any failed credential
+// check, so callers cannot tell an unknown email from a wrong password.
+func respondInvalidCredentials(c *gin.Context) {
+	c.JSON(http.StatusUnauthorized, gin.H{
+		"status": false,
+		"message": "Invalid email or password",
+		"error": "invalid_credentials",
+	})
+}
+
+func respondLoginThrottled(c *gin.Context, wait time.Duration) {
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"status": false,
+		"message": "Too many login attempts. Please try again later",
+		"error": "login_throttled",
+	})
+}
+
 func LoginUserHandler(c *gin.Context) {
 	var input schemas.LoginInput
 	if err := c.BindJSON(&input); err != nil {
@@ -27,35 +51,96 @@ func LoginUserHandler(c *gin.Context) {
 		return
 	}
 
-	var user models.User
-	if err := database.DB.Where("email = ?", input.Email).First(&user).Error; err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
+	wait, err := auth.CheckLoginThrottle(input.Email, c.ClientIP())
+	if err != nil {
+		log.Printf("Error checking login throttle: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
 			"status": false,
-			"message": "Invalid email or password",
-			"error": err.Error(),
+			"message": "Failed to process login",
 		})
 		return
 	}
+	if wait > 0 {
+		respondLoginThrottled(c, wait)
+		return
+	}
+
+	var user models.User
+	if err := database.DB.Where("LOWER(email) = LOWER(?)", strings.TrimSpace(input.Email)).First(&user).Error; err != nil {
+		// Spend the same time as a real password check so response times do
+		// not reveal which emails are registered.
+		bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(input.Password))
+		auth.RecordLoginFailure(input.Email, c.ClientIP())
+		respondInvalidCredentials(c)
+		return
+	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password)); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"status": false,
-			"message": "Invalid email or password",
-			"error": err.Error(),
+		auth.RecordLoginFailure(input.Email, c.ClientIP())
+		respondInvalidCredentials(c)
+		return
+	}
+
+	finishLogin(c, &user)
+}
+
+// finishLogin takes a user who passed the first factor, whether password or
+// single sign-on, through the remaining login checks. Failed attempts are
+// only forgotten once the user is fully authenticated, so a known password
+// cannot be used to reset the throttle on second factor guesses.
+func finishLogin(c *gin.Context, user *models.User) {
+	if err := auth.CheckLoginAllowed(user); err != nil {
+		respondLoginNotAllowed(c, err)
+		return
+	}
+
+	if user.TOTPEnabledAt != nil {
+		challenge, expiresAt, err := auth.IssueMFAChallenge(user.ID)
+		if err != nil {
+			log.Printf("Error issuing login challenge: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"status": false,
+				"message": "Failed to generate token",
+				"error": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status": true,
+			"message": "Enter the code from your authenticator app to finish logging in",
+			"data": schemas.MFAChallengeResponse{
+				MFARequired:    true,
+				ChallengeToken: challenge,
+				ExpiresAt:      expiresAt.Format("2006-01-02T15:04:05Z"),
+			},
 		})
 		return
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"userID": user.ID,
-		"exp":    time.Now().Add(time.Hour * 24).Unix(),
+	auth.ResetLoginFailures(user.Email)
+	respondWithTokens(c, user, sessionMeta(c))
+}
+
+func respondLoginNotAllowed(c *gin.Context, err error) {
+	message := "Please verify your email address before logging in"
+	if errors.Is(err, auth.ErrAccountDisabled) {
+		message = "This account has been disabled. Please contact support"
+	}
+
+	c.JSON(http.StatusForbidden, gin.H{
+		"status": false,
+		"message": message,
+		"error": err.Error(),
 	})
+}
 
-	
-	var jwtSecretKey = []byte(appConfig.AppConfig.EncryptionKey)
-	tokenString, err := token.SignedString(jwtSecretKey)
+// respondWithTokens opens a session for a fully authenticated user and
+// returns its token pair.
+func respondWithTokens(c *gin.Context, user *models.User, meta auth.SessionMeta) {
+	tokens, err := auth.IssueTokenPair(user.ID, meta)
 	if err != nil {
-		log.Printf("Error generating JWT: %v", err)
+		log.Printf("Error generating tokens: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status": false,
 			"message": "Failed to generate token",
@@ -66,7 +151,10 @@ func LoginUserHandler(c *gin.Context) {
 
 	var userResponse schemas.LoginResponse
 	userResponse.Email = user.Email
-	userResponse.Token = tokenString
+	userResponse.Token = tokens.AccessToken
+	userResponse.ExpiresAt = tokens.AccessExpiresAt.Format("2006-01-02T15:04:05Z")
+	userResponse.RefreshToken = tokens.RefreshToken
+	userResponse.RefreshExpiresAt = tokens.RefreshExpiresAt.Format("2006-01-02T15:04:05Z")
 
 	c.JSON(http.StatusOK, gin.H{
 		"status": true,
@@ -74,3 +162,10 @@ func LoginUserHandler(c *gin.Context) {
 		"data":  userResponse,
 	})
 }
+
+func sessionMeta(c *gin.Context) auth.SessionMeta {
+	return auth.SessionMeta{
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/souvik150/file-sharing-app/internal/auth"
+)
+
+func LogoutUserHandler(c *gin.Context) {
+	claims, exists := c.Get("tokenClaims")
+	if !exists {
+		log.Printf("Error getting token claims from context")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  false,
+			"message": "Failed to get token from context",
+			"error":   "token claims not found in context",
+		})
+		return
+	}
+
+	if err := auth.Logout(claims.(*auth.AccessClaims)); err != nil {
+		log.Printf("Error logging out: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  false,
+			"message": "Failed to log out",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "User logged out successfully",
+	})
+}
@@ -49,10 +49,16 @@ func GetCurrentUser(c *gin.Context) {
 	}
 
 	userResponse := schemas.GetCurrentUserResponse{
-		ID:        user.ID,
-		Email:     user.Email,
-		CreatedAt: user.CreatedAt.Format("2006-01-02T15:04:05Z"),
-		UpdatedAt: user.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+		ID:               user.ID,
+		Email:            user.Email,
+		Role:             user.Role,
+		EmailVerified:    user.EmailVerifiedAt != nil,
+		TwoFactorEnabled: user.TOTPEnabledAt != nil,
+		CreatedAt:        user.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		UpdatedAt:        user.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+	if user.DeletionScheduledAt != nil {
+		userResponse.DeletionScheduledAt = user.DeletionScheduledAt.Format("2006-01-02T15:04:05Z")
 	}
 
 	c.JSON(http.StatusOK, gin.H{
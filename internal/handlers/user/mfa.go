@@ -0,0 +1,339 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/souvik150/file-sharing-app/internal/auth"
+	"github.com/souvik150/file-sharing-app/internal/database"
+	"github.com/souvik150/file-sharing-app/internal/models"
+	"github.com/souvik150/file-sharing-app/internal/schemas"
+)
+
+func MFALoginHandler(c *gin.Context) {
+	var input schemas.MFALoginInput
+	if err := c.BindJSON(&input); err != nil || (input.Code == "" && input.RecoveryCode == "") {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": "Invalid request payload",
+			"error":   "challenge_token and either code or recovery_code are required",
+		})
+		return
+	}
+
+	wait, err := auth.CheckLoginThrottle("", c.ClientIP())
+	if err != nil {
+		log.Printf("Error checking login throttle: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  false,
+			"message": "Failed to process login",
+		})
+		return
+	}
+	if wait > 0 {
+		respondLoginThrottled(c, wait)
+		return
+	}
+
+	userID, err := auth.ConsumeMFAChallenge(input.ChallengeToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"status":  false,
+			"message": "Login challenge is invalid or expired. Please login again",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	var user models.User
+	if err := database.GetDB().Where("id = ?", userID).First(&user).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"status":  false,
+			"message": "Login challenge is invalid or expired. Please login again",
+			"error":   "user not found",
+		})
+		return
+	}
+
+	if wait, err := auth.CheckLoginThrottle(user.Email, c.ClientIP()); err == nil && wait > 0 {
+		respondLoginThrottled(c, wait)
+		return
+	}
+
+	if !verifySecondFactor(c, &user, input.Code, input.RecoveryCode) {
+		return
+	}
+	auth.ResetLoginFailures(user.Email)
+
+	// The account may have been disabled since the challenge was issued.
+	if err := auth.CheckLoginAllowed(&user); err != nil {
+		respondLoginNotAllowed(c, err)
+		return
+	}
+
+	meta := sessionMeta(c)
+	meta.MFAVerified = true
+	respondWithTokens(c, &user, meta)
+}
+
+// verifySecondFactor writes the error response itself and reports whether the
+// caller may continue.
+func verifySecondFactor(c *gin.Context, user *models.User, code, recoveryCode string) bool {
+	err := auth.VerifySecondFactor(user, code, recoveryCode)
+	if errors.Is(err, auth.ErrMFACodeInvalid) || errors.Is(err, auth.ErrMFANotEnabled) {
+		auth.RecordLoginFailure(user.Email, c.ClientIP())
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"status":  false,
+			"message": "Invalid two-factor code",
+			"error":   err.Error(),
+		})
+		return false
+	}
+	if err != nil {
+		log.Printf("Error verifying second factor: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  false,
+			"message": "Failed to verify two-factor code",
+			"error":   err.Error(),
+		})
+		return false
+	}
+
+	return true
+}
+
+func currentUser(c *gin.Context) (*models.User, bool) {
+	var user models.User
+	if err := database.GetDB().Where("id = ?", c.GetString("userID")).First(&user).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  false,
+			"message": "User not found",
+			"error":   err.Error(),
+		})
+		return nil, false
+	}
+	return &user, true
+}
+
+// sessionClaims returns the access token claims of an interactive session
+// together with its session ID.
+func sessionClaims(c *gin.Context) (*auth.AccessClaims, uuid.UUID, bool) {
+	value, _ := c.Get("tokenClaims")
+	claims, ok := value.(*auth.AccessClaims)
+	if ok {
+		if sessionID, err := uuid.Parse(claims.FamilyID); err == nil {
+			return claims, sessionID, true
+		}
+	}
+
+	c.JSON(http.StatusUnauthorized, gin.H{
+		"status":  false,
+		"message": "This action requires an interactive login",
+		"error":   "missing session claims",
+	})
+	return nil, uuid.Nil, false
+}
+
+func BeginTOTPEnrollmentHandler(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	secret, uri, err := auth.BeginTOTPEnrollment(user)
+	if errors.Is(err, auth.ErrMFAAlreadyEnabled) {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  false,
+			"message": "Two-factor authentication is already enabled",
+			"error":   err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Error starting TOTP enrollment: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  false,
+			"message": "Failed to start two-factor enrollment",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "Add the secret to your authenticator app, then confirm with a code",
+		"data": schemas.TOTPEnrollmentResponse{
+			Secret:     secret,
+			OTPAuthURI: uri,
+		},
+	})
+}
+
+func ConfirmTOTPEnrollmentHandler(c *gin.Context) {
+	var input schemas.TOTPCodeInput
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": "Invalid request payload",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	_, sessionID, ok := sessionClaims(c)
+	if !ok {
+		return
+	}
+
+	codes, err := auth.ConfirmTOTPEnrollment(user, input.Code, sessionID)
+	switch {
+	case errors.Is(err, auth.ErrMFAAlreadyEnabled), errors.Is(err, auth.ErrMFANotEnrolled):
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  false,
+			"message": "Two-factor enrollment cannot be confirmed",
+			"error":   err.Error(),
+		})
+		return
+	case errors.Is(err, auth.ErrMFACodeInvalid):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": "Invalid two-factor code",
+			"error":   err.Error(),
+		})
+		return
+	case err != nil:
+		log.Printf("Error confirming TOTP enrollment: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  false,
+			"message": "Failed to enable two-factor authentication",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "Two-factor authentication enabled. Store these recovery codes somewhere safe, they will not be shown again",
+		"data":    schemas.RecoveryCodesResponse{RecoveryCodes: codes},
+	})
+}
+
+// StepUpMFAHandler re-verifies the second factor within the current session
+// and returns an access token that satisfies RequireRecentMFA.
+func StepUpMFAHandler(c *gin.Context) {
+	var input schemas.TOTPCodeInput
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": "Invalid request payload",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	claims, _, ok := sessionClaims(c)
+	if !ok {
+		return
+	}
+
+	if !verifySecondFactor(c, user, input.Code, "") {
+		return
+	}
+
+	token, expiresAt, err := auth.StepUp(claims)
+	if err != nil {
+		log.Printf("Error issuing stepped up token: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  false,
+			"message": "Failed to generate token",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "Second factor verified",
+		"data": gin.H{
+			"token":      token,
+			"expires_at": expiresAt.Format("2006-01-02T15:04:05Z"),
+		},
+	})
+}
+
+func RegenerateRecoveryCodesHandler(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	codes, err := auth.RegenerateRecoveryCodes(user)
+	if errors.Is(err, auth.ErrMFANotEnabled) {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  false,
+			"message": "Two-factor authentication is not enabled",
+			"error":   err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Error regenerating recovery codes: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  false,
+			"message": "Failed to regenerate recovery codes",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "Recovery codes regenerated. Previous codes no longer work",
+		"data":    schemas.RecoveryCodesResponse{RecoveryCodes: codes},
+	})
+}
+
+func DisableTOTPHandler(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	err := auth.DisableTOTP(user)
+	if errors.Is(err, auth.ErrMFANotEnabled) {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  false,
+			"message": "Two-factor authentication is not enabled",
+			"error":   err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Error disabling TOTP: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  false,
+			"message": "Failed to disable two-factor authentication",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "Two-factor authentication disabled",
+	})
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/souvik150/file-sharing-app/internal/auth"
+	"github.com/souvik150/file-sharing-app/internal/config"
+)
+
+// oidcStateCookie ties a login to the browser that started it, so a callback
+// URL from someone else's login cannot be replayed in a victim's browser.
+const oidcStateCookie = "oidc_state"
+
+func setOIDCStateCookie(c *gin.Context, value string, maxAge int) {
+	secure := strings.HasPrefix(config.AppConfig.BackendURL, "https://")
+	// Lax still sends the cookie on the top-level redirect back from the
+	// identity provider.
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, value, maxAge, "/", "", secure, true)
+}
+
+// OIDCLoginHandler redirects the browser to the corporate identity provider.
+func OIDCLoginHandler(c *gin.Context) {
+	authURL, state, err := auth.BeginOIDCLogin()
+	if errors.Is(err, auth.ErrOIDCDisabled) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  false,
+			"message": "Single sign-on is not available",
+			"error":   err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Error starting OIDC login: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  false,
+			"message": "Failed to start single sign-on",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	setOIDCStateCookie(c, state, int(auth.OIDCStateTTL.Seconds()))
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// OIDCCallbackHandler finishes the authorization code flow and logs the user
+// in exactly like a password login would.
+func OIDCCallbackHandler(c *gin.Context) {
+	if errParam := c.Query("error"); errParam != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"status":  false,
+			"message": "Single sign-on was not completed",
+			"error":   errParam + ": " + c.Query("error_description"),
+		})
+		return
+	}
+
+	state, code := c.Query("state"), c.Query("code")
+	if state == "" || code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": "Invalid callback request",
+			"error":   "state and code query parameters are required",
+		})
+		return
+	}
+
+	cookieState, _ := c.Cookie(oidcStateCookie)
+	setOIDCStateCookie(c, "", -1)
+	if subtle.ConstantTimeCompare([]byte(cookieState), []byte(state)) != 1 {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"status":  false,
+			"message": "Single sign-on failed",
+			"error":   auth.ErrOIDCStateInvalid.Error(),
+		})
+		return
+	}
+
+	user, err := auth.CompleteOIDCLogin(c.Request.Context(), state, code)
+	switch {
+	case errors.Is(err, auth.ErrOIDCDisabled):
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  false,
+			"message": "Single sign-on is not available",
+			"error":   err.Error(),
+		})
+		return
+	case errors.Is(err, auth.ErrOIDCStateInvalid), errors.Is(err, auth.ErrOIDCEmailUnverified):
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"status":  false,
+			"message": "Single sign-on failed",
+			"error":   err.Error(),
+		})
+		return
+	case errors.Is(err, auth.ErrOIDCAccountUnavailable):
+		c.JSON(http.StatusForbidden, gin.H{
+			"status":  false,
+			"message": "This account is no longer available",
+			"error":   err.Error(),
+		})
+		return
+	case err != nil:
+		log.Printf("Error completing OIDC login: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{
+			"status":  false,
+			"message": "Single sign-on failed",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	finishLogin(c, user)
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/souvik150/file-sharing-app/internal/auth"
+	"github.com/souvik150/file-sharing-app/internal/database"
+	"github.com/souvik150/file-sharing-app/internal/models"
+	"github.com/souvik150/file-sharing-app/internal/schemas"
+)
+
+func RequestPasswordResetHandler(c *gin.Context) {
+	var input schemas.PasswordResetRequestInput
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": "Invalid request payload",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	// Answer the same way whether or not the account exists so the endpoint
+	// cannot be used to discover registered emails.
+	var user models.User
+	if err := database.GetDB().Where("LOWER(email) = LOWER(?)", strings.TrimSpace(input.Email)).First(&user).Error; err == nil {
+		if err := auth.SendPasswordReset(&user); err != nil {
+			log.Printf("Error sending password reset email: %v", err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "If an account exists for this email, a password reset token has been sent",
+	})
+}
+
+func ConfirmPasswordResetHandler(c *gin.Context) {
+	var input schemas.PasswordResetConfirmInput
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": "Invalid request payload",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	err := auth.ResetPassword(input.Token, input.NewPassword)
+	var policyErr *auth.PasswordPolicyError
+	if errors.As(err, &policyErr) {
+		respondWeakPassword(c, policyErr)
+		return
+	}
+	if errors.Is(err, auth.ErrResetTokenInvalid) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": "Invalid or expired password reset token",
+			"error":   err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Error resetting password: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  false,
+			"message": "Failed to reset password",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "Password reset successfully. Please login with your new password",
+	})
+}
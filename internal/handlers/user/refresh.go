@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/souvik150/file-sharing-app/internal/auth"
+	"github.com/souvik150/file-sharing-app/internal/schemas"
+)
+
+func RefreshTokenHandler(c *gin.Context) {
+	var input schemas.RefreshTokenInput
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": "Invalid request payload",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	tokens, err := auth.RotateRefreshToken(input.RefreshToken, sessionMeta(c))
+	if errors.Is(err, auth.ErrRefreshTokenInvalid) || errors.Is(err, auth.ErrRefreshTokenReused) {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"status":  false,
+			"message": "Invalid refresh token",
+			"error":   err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Error rotating refresh token: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  false,
+			"message": "Failed to refresh token",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "Token refreshed successfully",
+		"data": schemas.TokenResponse{
+			Token:            tokens.AccessToken,
+			ExpiresAt:        tokens.AccessExpiresAt.Format("2006-01-02T15:04:05Z"),
+			RefreshToken:     tokens.RefreshToken,
+			RefreshExpiresAt: tokens.RefreshExpiresAt.Format("2006-01-02T15:04:05Z"),
+		},
+	})
+}
@@ -1,13 +1,13 @@
 package handlers
 
 import (
+	"errors"
 	"log"
 	"net/http"
 
-	"golang.org/x/crypto/bcrypt"
-
 	"github.com/gin-gonic/gin"
 
+	"github.com/souvik150/file-sharing-app/internal/auth"
 	"github.com/souvik150/file-sharing-app/internal/database"
 	"github.com/souvik150/file-sharing-app/internal/models"
 	"github.com/souvik150/file-sharing-app/internal/schemas"
@@ -24,7 +24,41 @@ func RegisterUserHandler(c *gin.Context) {
 		return
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
+	email, err := auth.NormalizeEmail(input.Email)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": "Invalid email address",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	var policyErr *auth.PasswordPolicyError
+	if errors.As(auth.ValidatePassword(input.Password, email), &policyErr) {
+		respondWeakPassword(c, policyErr)
+		return
+	}
+
+	var existing int64
+	if err := database.DB.Model(&models.User{}).Where("LOWER(email) = ?", email).Count(&existing).Error; err != nil {
+		log.Printf("Error checking for existing user: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  false,
+			"message": "Failed to create user",
+			"error":   err.Error(),
+		})
+		return
+	}
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  false,
+			"message": "An account with this email already exists",
+		})
+		return
+	}
+
+	hashedPassword, err := auth.HashPassword(input.Password)
 	if err != nil {
 		log.Printf("Error hashing password: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -36,8 +70,8 @@ func RegisterUserHandler(c *gin.Context) {
 	}
 
 	user := models.User{
-		Email:    input.Email,
-		Password: string(hashedPassword),
+		Email:    email,
+		Password: hashedPassword,
 	}
 
 	if err := database.DB.Create(&user).Error; err != nil {
@@ -50,13 +84,28 @@ func RegisterUserHandler(c *gin.Context) {
 		return
 	}
 
+	if err := auth.SendEmailVerification(&user); err != nil {
+		log.Printf("Error sending verification email to %s: %v", user.Email, err)
+	}
+
 	var userResponse schemas.UserSignupResponse
 	userResponse.ID = user.ID
 	userResponse.Email = user.Email
 
 	c.JSON(http.StatusOK, gin.H{
 		"status":  true,
-		"message": "User created successfully. Please login to continue",
+		"message": "User created successfully. Check your email to verify your address, then login to continue",
 		"data":    userResponse,
 	})
 }
+
+// respondWeakPassword lists every password rule that was broken so clients
+// can show them all at once.
+func respondWeakPassword(c *gin.Context, policyErr *auth.PasswordPolicyError) {
+	c.JSON(http.StatusBadRequest, gin.H{
+		"status":   false,
+		"message":  "Password does not meet the password policy",
+		"error":    "weak_password",
+		"problems": policyErr.Problems,
+	})
+}
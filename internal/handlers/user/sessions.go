@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/souvik150/file-sharing-app/internal/auth"
+	"github.com/souvik150/file-sharing-app/internal/database"
+	"github.com/souvik150/file-sharing-app/internal/models"
+	"github.com/souvik150/file-sharing-app/internal/schemas"
+)
+
+func ListSessionsHandler(c *gin.Context) {
+	claims := c.MustGet("tokenClaims").(*auth.AccessClaims)
+
+	var sessions []models.Session
+	err := database.GetDB().
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", claims.UserID, time.Now()).
+		Order("last_used_at DESC").
+		Find(&sessions).Error
+	if err != nil {
+		log.Printf("Error fetching sessions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  false,
+			"message": "Failed to fetch sessions",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	sessionsResponse := []schemas.SessionResponse{}
+	for _, session := range sessions {
+		sessionsResponse = append(sessionsResponse, schemas.SessionResponse{
+			ID:         session.ID,
+			IPAddress:  session.IPAddress,
+			UserAgent:  session.UserAgent,
+			CreatedAt:  session.CreatedAt.Format("2006-01-02T15:04:05Z"),
+			LastUsedAt: session.LastUsedAt.Format("2006-01-02T15:04:05Z"),
+			ExpiresAt:  session.ExpiresAt.Format("2006-01-02T15:04:05Z"),
+			Current:    session.ID.String() == claims.FamilyID,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "Sessions fetched successfully",
+		"data":    sessionsResponse,
+	})
+}
+
+func RevokeSessionHandler(c *gin.Context) {
+	claims := c.MustGet("tokenClaims").(*auth.AccessClaims)
+
+	sessionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": "Invalid session ID",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	var session models.Session
+	if err := database.GetDB().Where("id = ? AND user_id = ?", sessionID, claims.UserID).First(&session).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  false,
+			"message": "Session not found",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if err := auth.RevokeSession(session.ID); err != nil {
+		log.Printf("Error revoking session: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  false,
+			"message": "Failed to revoke session",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "Session revoked successfully",
+	})
+}
+
+func RevokeOtherSessionsHandler(c *gin.Context) {
+	claims := c.MustGet("tokenClaims").(*auth.AccessClaims)
+
+	userID := uuid.MustParse(claims.UserID)
+	currentSessionID := uuid.MustParse(claims.FamilyID)
+
+	revoked, err := auth.RevokeUserSessions(userID, currentSessionID)
+	if err != nil {
+		log.Printf("Error revoking sessions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  false,
+			"message": "Failed to revoke sessions",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "Other sessions revoked successfully",
+		"data":    gin.H{"revoked": revoked},
+	})
+}
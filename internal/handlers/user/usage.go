@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/souvik150/file-sharing-app/internal/database"
+	"github.com/souvik150/file-sharing-app/internal/files"
+	"github.com/souvik150/file-sharing-app/internal/models"
+	"github.com/souvik150/file-sharing-app/internal/schemas"
+)
+
+func GetUsageHandler(c *gin.Context) {
+	db := database.GetDB()
+
+	var user models.User
+	if err := db.Where("id = ?", c.GetString("userID")).First(&user).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  false,
+			"message": "User not found",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	usage := schemas.UsageResponse{
+		UsedBytes:  user.StorageUsed,
+		QuotaBytes: files.Quota(&user),
+		ByType:     []schemas.TypeStorageUsage{},
+	}
+	if usage.QuotaBytes > 0 {
+		available := max(usage.QuotaBytes-usage.UsedBytes, 0)
+		usage.AvailableBytes = &available
+	}
+
+	// The breakdown counts every version, like the quota does.
+	err := db.Raw(`SELECT
+			COUNT(DISTINCT files.id) FILTER (WHERE files.deleted_at IS NULL) AS file_count,
+			COALESCE(SUM(file_versions.size) FILTER (WHERE files.deleted_at IS NOT NULL), 0) AS trash_bytes,
+			COALESCE(SUM(file_versions.size) FILTER (WHERE file_versions.version <> files.current_version), 0) AS version_bytes
+		FROM files
+		JOIN file_versions ON file_versions.file_id = files.id
+		WHERE files.owner_id = ?`, user.ID).
+		Scan(&usage).Error
+	if err == nil {
+		err = db.Raw(`SELECT files.file_type, COUNT(DISTINCT files.id) AS file_count, SUM(file_versions.size) AS bytes
+			FROM files
+			JOIN file_versions ON file_versions.file_id = files.id
+			WHERE files.owner_id = ?
+			GROUP BY files.file_type
+			ORDER BY bytes DESC`, user.ID).
+			Scan(&usage.ByType).Error
+	}
+	if err != nil {
+		log.Printf("Error computing storage usage: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  false,
+			"message": "Failed to compute storage usage",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "Storage usage fetched successfully",
+		"data":    usage,
+	})
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/souvik150/file-sharing-app/internal/config"
+	"github.com/souvik150/file-sharing-app/internal/database"
+	"github.com/souvik150/file-sharing-app/internal/files"
+	"github.com/souvik150/file-sharing-app/internal/models"
+	"github.com/souvik150/file-sharing-app/internal/schemas"
+)
+
+// versionRetentionResponse reports the policy in effect, resolving the
+// server default for users who have not set one.
+func versionRetentionResponse(user *models.User) schemas.VersionRetentionResponse {
+	if user.VersionRetentionMode == "" {
+		return schemas.VersionRetentionResponse{
+			Mode:  models.VersionRetentionCount,
+			Value: config.AppConfig.VersionRetentionCount,
+		}
+	}
+	return schemas.VersionRetentionResponse{
+		Mode:  user.VersionRetentionMode,
+		Value: user.VersionRetentionValue,
+	}
+}
+
+func GetVersionRetentionHandler(c *gin.Context) {
+	var user models.User
+	if err := database.GetDB().Where("id = ?", c.GetString("userID")).First(&user).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  false,
+			"message": "User not found",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "Version retention fetched successfully",
+		"data":    versionRetentionResponse(&user),
+	})
+}
+
+func UpdateVersionRetentionHandler(c *gin.Context) {
+	var input schemas.VersionRetentionInput
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": "Invalid request payload",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	var user models.User
+	if err := database.GetDB().Where("id = ?", c.GetString("userID")).First(&user).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  false,
+			"message": "User not found",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	err := files.SetVersionRetention(&user, input.Mode, input.Value)
+	if errors.Is(err, files.ErrInvalidRetention) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": "Invalid version retention",
+			"error":   err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Error updating version retention: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  false,
+			"message": "Failed to update version retention",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "Version retention updated successfully",
+		"data":    versionRetentionResponse(&user),
+	})
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditLog records security relevant events. ActorID is empty for events
+// triggered by anonymous requests, such as failed logins.
+type AuditLog struct {
+	ID         uuid.UUID  `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	ActorID    *uuid.UUID `gorm:"type:uuid;index"`
+	Action     string     `gorm:"not null;index"`
+	TargetType string
+	TargetID   string `gorm:"index"`
+	IPAddress  string
+	Details    string
+	CreatedAt  time.Time `gorm:"index"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Blob is a stored object addressed by the SHA-256 of its content. Identical
+// uploads by the same owner share one blob; RefCount counts the file versions
+// pointing at it. Blobs that drop to zero references are garbage collected,
+// and only referenced blobs take part in the uniqueness check so a new upload
+// never waits on one being collected.
+type Blob struct {
+	ID        uuid.UUID `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	OwnerID   uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_blobs_owner_sha256,where:ref_count > 0"`
+	Owner     User      `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	SHA256    string    `gorm:"column:sha256;not null;uniqueIndex:idx_blobs_owner_sha256,where:ref_count > 0"`
+	ObjectKey string    `gorm:"not null"`
+	Size      int64     `gorm:"not null"`
+	RefCount  int       `gorm:"not null;default:0;index"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
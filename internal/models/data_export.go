@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ExportPending    = "pending"
+	ExportProcessing = "processing"
+	ExportReady      = "ready"
+	ExportFailed     = "failed"
+)
+
+// DataExport tracks a personal data archive. The archive lives on local disk
+// at FilePath until ExpiresAt; only the hash of its download token is stored.
+type DataExport struct {
+	ID                uuid.UUID `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	UserID            uuid.UUID `gorm:"type:uuid;not null;index"`
+	User              User      `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Status            string    `gorm:"not null;index"`
+	Error             string
+	FilePath          string
+	Size              int64
+	DownloadTokenHash string `gorm:"index"`
+	ExpiresAt         *time.Time
+	CompletedAt       *time.Time
+	CreatedAt         time.Time
+}
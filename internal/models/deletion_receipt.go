@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DeletionReceipt is kept after an account is purged as proof of what was
+// removed. It deliberately holds no personal data beyond a hash of the email
+// address, so a former user can still be matched to their receipt.
+type DeletionReceipt struct {
+	ID                 uuid.UUID `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	UserID             uuid.UUID `gorm:"type:uuid;index;not null"`
+	EmailHash          string    `gorm:"index;not null"`
+	RequestedAt        time.Time
+	CompletedAt        time.Time
+	FilesDeleted       int
+	BytesDeleted       int64
+	SharedLinksDeleted int64
+	SessionsRevoked    int
+	APIKeysDeleted     int64
+	CreatedAt          time.Time
+}
@@ -4,18 +4,57 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type File struct {
-	ID           uuid.UUID      `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
-	FileName     string         `gorm:"not null;index"`
-	OwnerID      uuid.UUID      `gorm:"not null"`
-	Owner        User           `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
-	Size         int64          `gorm:"not null"`
-	FileType     string         `gorm:"index"`
-	CreatedAt    time.Time      `gorm:"index"`
-	UpdatedAt    time.Time
-	AccessedAt   time.Time
+	ID       uuid.UUID  `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	FileName string     `gorm:"not null;index"`
+	OwnerID  uuid.UUID  `gorm:"not null"`
+	Owner    User       `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	FolderID *uuid.UUID `gorm:"type:uuid;index"`
+	Folder   *Folder    `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
+	Size     int64      `gorm:"not null"`
+	FileType string     `gorm:"index"`
+	// MimeType is sniffed from the content; TypeMismatch is set when the
+	// extension claims something else.
+	MimeType     string `gorm:"index"`
+	TypeMismatch bool   `gorm:"not null;default:false"`
+	// ImageWidth, ImageHeight and CapturedAt are kept from an image's
+	// metadata, which is removed from the content when MetadataStripped.
+	ImageWidth       int
+	ImageHeight      int
+	CapturedAt       *time.Time
+	MetadataStripped bool `gorm:"not null;default:false"`
+	// ObjectKey is the S3 key of the current version. Files uploaded before
+	// versioning are stored under their ID and leave it empty.
+	ObjectKey      string
+	CurrentVersion int `gorm:"not null;default:1"`
+	// SHA256 is the hex digest of the current version's content, empty until
+	// its upload completes.
+	SHA256 string `gorm:"column:sha256;index"`
+	// Corrupted is set when the integrity scrub finds the current version's
+	// stored object unreadable or no longer matching SHA256.
+	Corrupted     bool          `gorm:"not null;default:false;index"`
+	Versions      []FileVersion `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	CreatedAt     time.Time     `gorm:"index"`
+	UpdatedAt     time.Time
+	AccessedAt    time.Time
 	DeletedStatus bool
-	DeletedAt    time.Time `gorm:"index"`
+	DeletedAt     gorm.DeletedAt `gorm:"index"`
+	Tags          []FileTag      `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Metadata      []FileMetadata `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Content       *FileContent   `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Preview       *FilePreview   `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	// SearchVector is maintained by the search package and never read or
+	// written through the model.
+	SearchVector string `gorm:"type:tsvector;index:idx_files_search_vector,type:gin;->:false;<-:false"`
+}
+
+// StorageKey is the S3 key holding the file's current content.
+func (f *File) StorageKey() string {
+	if f.ObjectKey != "" {
+		return f.ObjectKey
+	}
+	return f.ID.String()
 }
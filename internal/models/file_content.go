@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FileContent holds the text extracted from a file for full-text search.
+// Extractor records how the text was obtained, or "none" for formats that
+// have no text to index, so the indexing job does not retry them.
+type FileContent struct {
+	FileID    uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Text      string
+	Extractor string `gorm:"not null"`
+	IndexedAt time.Time
+}
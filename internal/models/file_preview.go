@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	PreviewReady       = "ready"
+	PreviewUnsupported = "unsupported"
+	PreviewFailed      = "failed"
+)
+
+// FilePreview records the thumbnails of a file's current version. Status is
+// "ready" once they are stored, "unsupported" for content that is not an
+// image or too large to preview and "failed" for images that could not be
+// read, so the thumbnail job leaves the file alone until its content changes.
+type FilePreview struct {
+	FileID  uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Version int       `gorm:"not null"`
+	Status  string    `gorm:"not null"`
+	// Format is how the thumbnails are encoded: "jpeg" for opaque images and
+	// "png" for those with transparency.
+	Format string
+	// Width and Height are the dimensions of the original image.
+	Width       int
+	Height      int
+	GeneratedAt time.Time
+}
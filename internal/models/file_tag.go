@@ -0,0 +1,22 @@
+package models
+
+import "github.com/google/uuid"
+
+// FileTag attaches a normalized, lowercase tag to a file. The tag index backs
+// the tag filters on /my-files.
+type FileTag struct {
+	FileID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Tag    string    `gorm:"primaryKey;index"`
+}
+
+// FileMetadata is a user defined key/value pair on a file. Keys are unique per
+// file; the key/value index backs the metadata filters on /my-files.
+type FileMetadata struct {
+	FileID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Key    string    `gorm:"primaryKey;index:idx_file_metadata_key_value,priority:1"`
+	Value  string    `gorm:"not null;index:idx_file_metadata_key_value,priority:2"`
+}
+
+func (FileMetadata) TableName() string {
+	return "file_metadata"
+}
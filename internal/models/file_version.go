@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FileVersion is one revision of a file's content. Version numbers start at
+// 1 and increase with every upload or restore; the file row mirrors the
+// current one. Identical versions of the same owner share their S3 object
+// through a blob.
+type FileVersion struct {
+	ID           uuid.UUID `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	FileID       uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_file_versions_file_version,priority:1"`
+	Version      int       `gorm:"not null;uniqueIndex:idx_file_versions_file_version,priority:2"`
+	ObjectKey    string    `gorm:"not null"`
+	FileName     string    `gorm:"not null"`
+	FileType     string
+	MimeType     string
+	TypeMismatch bool  `gorm:"not null;default:false"`
+	Size         int64 `gorm:"not null"`
+	// ImageWidth, ImageHeight and CapturedAt are kept from an image's
+	// metadata, which is removed from the content when MetadataStripped.
+	ImageWidth       int
+	ImageHeight      int
+	CapturedAt       *time.Time
+	MetadataStripped bool   `gorm:"not null;default:false"`
+	SHA256           string `gorm:"column:sha256"`
+	// BlobID is the deduplicated object holding this version. Versions
+	// uploaded before deduplication own their object outright and have none.
+	BlobID *uuid.UUID `gorm:"type:uuid;index"`
+	Blob   *Blob      `gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+	// Pending is set while a first upload's content is still on its way to
+	// S3, until it is linked to its blob.
+	Pending bool `gorm:"not null;default:false"`
+	// VerifiedAt is when the integrity scrub last read the stored object back,
+	// and Corrupted whether it failed to decrypt or match SHA256.
+	VerifiedAt *time.Time `gorm:"index"`
+	Corrupted  bool       `gorm:"not null;default:false"`
+	// RestoredFrom is the version this one was restored from, if any.
+	RestoredFrom *int
+	CreatedAt    time.Time `gorm:"index"`
+}
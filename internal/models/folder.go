@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Folder groups files into a tree per owner. A nil ParentID places the folder
+// at the owner's root. Names are unique among live siblings, case
+// insensitively; see idx_folders_unique_name in the migration.
+type Folder struct {
+	ID        uuid.UUID  `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	OwnerID   uuid.UUID  `gorm:"type:uuid;not null;index"`
+	Owner     User       `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	ParentID  *uuid.UUID `gorm:"type:uuid;index"`
+	Parent    *Folder    `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Name      string     `gorm:"not null"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RefreshToken is a single link in a rotating refresh token chain. Every token
+// issued from the same login shares a FamilyID, which is the ID of the Session
+// it belongs to, so reuse of a rotated token can revoke the whole chain at
+// once. Only the SHA-256 hash of the token is stored.
+type RefreshToken struct {
+	ID           uuid.UUID  `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	UserID       uuid.UUID  `gorm:"type:uuid;not null;index"`
+	User         User       `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	FamilyID     uuid.UUID  `gorm:"type:uuid;not null;index"`
+	TokenHash    string     `gorm:"not null;uniqueIndex"`
+	ExpiresAt    time.Time  `gorm:"not null"`
+	RevokedAt    *time.Time `gorm:"index"`
+	ReplacedByID *uuid.UUID `gorm:"type:uuid"`
+	CreatedAt    time.Time
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session is one login of a user on a device. Its ID doubles as the family ID
+// of the refresh tokens rotated within it.
+type Session struct {
+	ID         uuid.UUID `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	UserID     uuid.UUID `gorm:"type:uuid;not null;index"`
+	User       User      `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	IPAddress  string
+	UserAgent  string
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+	ExpiresAt  time.Time  `gorm:"not null"`
+	RevokedAt  *time.Time `gorm:"index"`
+	// MFAVerifiedAt is the last time a second factor was presented in this
+	// session, used to gate sensitive actions.
+	MFAVerifiedAt *time.Time
+}
@@ -8,12 +8,55 @@ import (
 	"github.com/google/uuid"
 )
 
+const (
+	RoleUser    = "user"
+	RoleAdmin   = "admin"
+	RoleAuditor = "auditor"
+)
+
+var Roles = []string{RoleUser, RoleAdmin, RoleAuditor}
+
+const (
+	VersionRetentionCount = "count"
+	VersionRetentionDays  = "days"
+)
+
 type User struct {
-	ID        uuid.UUID      `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
-	Email     string         `gorm:"unique;not null"`
-	Password  string         `gorm:"not null"`
-	Files     []File         `gorm:"foreignKey:OwnerID"`
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	DeletedAt gorm.DeletedAt `gorm:"index"`
+	ID       uuid.UUID `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	Email    string    `gorm:"unique;not null"`
+	Password string    `gorm:"not null"`
+	Role     string    `gorm:"not null;default:'user';index"`
+	// DisabledAt blocks login and API access while set.
+	DisabledAt            *time.Time
+	EmailVerifiedAt       *time.Time
+	VerificationTokenHash string `gorm:"index"`
+	VerificationExpiresAt *time.Time
+	// TOTPSecret is AES-GCM encrypted and base64 encoded. It is set during
+	// enrollment but only enforced once TOTPEnabledAt is set.
+	TOTPSecret       string
+	TOTPEnabledAt    *time.Time
+	TOTPLastUsedStep int64
+	// DeletionScheduledAt is when a requested account deletion will be
+	// carried out. Clearing it before then cancels the deletion.
+	DeletionRequestedAt *time.Time
+	DeletionScheduledAt *time.Time `gorm:"index"`
+	Files               []File     `gorm:"foreignKey:OwnerID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	// VersionRetentionMode is "count" to keep the newest
+	// VersionRetentionValue versions of each file, or "days" to keep old
+	// versions that many days. Empty uses the server default.
+	VersionRetentionMode  string
+	VersionRetentionValue int
+	// StorageQuota overrides STORAGE_QUOTA in bytes, 0 meaning unlimited.
+	// StorageUsed counts every stored version of the user's files, including
+	// those in the trash, plus StorageReserved: bytes of uploads still in
+	// progress that no version accounts for yet.
+	StorageQuota    *int64
+	StorageUsed     int64 `gorm:"not null;default:0"`
+	StorageReserved int64 `gorm:"not null;default:0"`
+	// StripImageMetadata removes EXIF and XMP from JPEG and PNG uploads
+	// unless an upload asks otherwise.
+	StripImageMetadata bool `gorm:"not null;default:false"`
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+	DeletedAt          gorm.DeletedAt `gorm:"index"`
 }
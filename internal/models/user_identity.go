@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserIdentity links a user to an account at an external OpenID Connect
+// provider, identified by the provider's issuer and subject.
+type UserIdentity struct {
+	ID          uuid.UUID `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	UserID      uuid.UUID `gorm:"type:uuid;not null;index"`
+	User        User      `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Issuer      string    `gorm:"not null;uniqueIndex:idx_identity_issuer_subject"`
+	Subject     string    `gorm:"not null;uniqueIndex:idx_identity_issuer_subject"`
+	Email       string
+	CreatedAt   time.Time
+	LastLoginAt time.Time
+}
@@ -3,25 +3,103 @@ package routes
 import (
 	"github.com/gin-gonic/gin"
 
+	"github.com/souvik150/file-sharing-app/internal/auth"
+	adminHandlers "github.com/souvik150/file-sharing-app/internal/handlers/admin"
 	fileHandlers "github.com/souvik150/file-sharing-app/internal/handlers/file"
 	userHandlers "github.com/souvik150/file-sharing-app/internal/handlers/user"
+	"github.com/souvik150/file-sharing-app/internal/models"
 	"github.com/souvik150/file-sharing-app/pkg/middleware"
 )
 
 func SetupRoutes(r *gin.Engine) {
 	r.POST("/register", userHandlers.RegisterUserHandler)
 	r.POST("/login", userHandlers.LoginUserHandler)
+	r.POST("/login/mfa", userHandlers.MFALoginHandler)
+	r.GET("/auth/oidc/login", userHandlers.OIDCLoginHandler)
+	r.GET("/auth/oidc/callback", userHandlers.OIDCCallbackHandler)
+	r.POST("/refresh", userHandlers.RefreshTokenHandler)
+	r.GET("/verify-email", userHandlers.VerifyEmailHandler)
+	r.POST("/password-reset/request", userHandlers.RequestPasswordResetHandler)
+	r.POST("/password-reset/confirm", userHandlers.ConfirmPasswordResetHandler)
 	r.GET("/share/:share_token", fileHandlers.ServeSharedFileHandler)
+	r.GET("/.well-known/jwks.json", userHandlers.JWKSHandler)
+	r.GET("/exports/:id/download", userHandlers.DownloadExportHandler)
 
 	protected := r.Group("/")
 	protected.Use(middleware.AuthMiddleware()) 
 	{
-		protected.POST("/upload", fileHandlers.UploadMultipleFilesHandler)
-		protected.GET("/generate/:id", fileHandlers.ShareFileHandler)
-		protected.DELETE("/delete/:id", fileHandlers.DeleteFileHandler)
-		protected.GET("/deleted-files", fileHandlers.GetUserDeletedFilesHandler)
-		protected.GET("/my-files", fileHandlers.GetUserFilesHandler)
-		protected.PATCH("/update", fileHandlers.UpdateFileHandler)
+		protected.POST("/upload", middleware.RequireScope(auth.ScopeFilesWrite), fileHandlers.UploadMultipleFilesHandler)
+		protected.GET("/generate/:id", middleware.RequireScope(auth.ScopeShareCreate), fileHandlers.ShareFileHandler)
+		protected.DELETE("/delete/:id", middleware.RequireScope(auth.ScopeFilesWrite), fileHandlers.DeleteFileHandler)
+		protected.GET("/deleted-files", middleware.RequireScope(auth.ScopeFilesRead), fileHandlers.GetUserDeletedFilesHandler)
+		protected.POST("/trash/:id/restore", middleware.RequireScope(auth.ScopeFilesWrite), fileHandlers.RestoreFileHandler)
+		protected.DELETE("/trash/:id", middleware.RequireScope(auth.ScopeFilesWrite), fileHandlers.PermanentlyDeleteFileHandler)
+		protected.DELETE("/trash", middleware.RequireScope(auth.ScopeFilesWrite), fileHandlers.EmptyTrashHandler)
+		protected.GET("/my-files", middleware.RequireScope(auth.ScopeFilesRead), fileHandlers.GetUserFilesHandler)
+		protected.PATCH("/update", middleware.RequireScope(auth.ScopeFilesWrite), fileHandlers.UpdateFileHandler)
 		protected.GET("/me", userHandlers.GetCurrentUser)
+		protected.POST("/files/:id/move", middleware.RequireScope(auth.ScopeFilesWrite), fileHandlers.MoveFileHandler)
+		protected.POST("/files/:id/versions", middleware.RequireScope(auth.ScopeFilesWrite), fileHandlers.UploadVersionHandler)
+		protected.GET("/files/:id/versions", middleware.RequireScope(auth.ScopeFilesRead), fileHandlers.ListVersionsHandler)
+		protected.GET("/files/:id/thumbnail", middleware.RequireScope(auth.ScopeFilesRead), fileHandlers.GetThumbnailHandler)
+		protected.GET("/files/:id/versions/:version/download", middleware.RequireScope(auth.ScopeFilesRead), fileHandlers.DownloadVersionHandler)
+		protected.POST("/files/:id/versions/:version/restore", middleware.RequireScope(auth.ScopeFilesWrite), fileHandlers.RestoreVersionHandler)
+		protected.PATCH("/files/tags", middleware.RequireScope(auth.ScopeFilesWrite), fileHandlers.UpdateTagsHandler)
+		protected.PATCH("/files/metadata", middleware.RequireScope(auth.ScopeFilesWrite), fileHandlers.UpdateMetadataHandler)
+		protected.POST("/folders", middleware.RequireScope(auth.ScopeFilesWrite), fileHandlers.CreateFolderHandler)
+		protected.GET("/folders", middleware.RequireScope(auth.ScopeFilesRead), fileHandlers.GetRootFolderHandler)
+		protected.GET("/folders/:id", middleware.RequireScope(auth.ScopeFilesRead), fileHandlers.GetFolderHandler)
+		protected.PATCH("/folders/:id", middleware.RequireScope(auth.ScopeFilesWrite), fileHandlers.RenameFolderHandler)
+		protected.POST("/folders/:id/move", middleware.RequireScope(auth.ScopeFilesWrite), fileHandlers.MoveFolderHandler)
+		protected.DELETE("/folders/:id", middleware.RequireScope(auth.ScopeFilesWrite), fileHandlers.DeleteFolderHandler)
+	}
+
+	account := protected.Group("/")
+	account.Use(middleware.RequireSession())
+	{
+		account.POST("/logout", userHandlers.LogoutUserHandler)
+		account.POST("/verify-email/resend", userHandlers.ResendVerificationHandler)
+		account.GET("/me/sessions", userHandlers.ListSessionsHandler)
+		account.DELETE("/me/sessions", userHandlers.RevokeOtherSessionsHandler)
+		account.DELETE("/me/sessions/:id", userHandlers.RevokeSessionHandler)
+		account.POST("/me/password", middleware.RequireRecentMFA(), userHandlers.ChangePasswordHandler)
+		account.POST("/me/deletion", middleware.RequireRecentMFA(), userHandlers.RequestAccountDeletionHandler)
+		account.DELETE("/me/deletion", userHandlers.CancelAccountDeletionHandler)
+		account.POST("/me/exports", middleware.RequireRecentMFA(), userHandlers.RequestExportHandler)
+		account.GET("/me/exports", userHandlers.ListExportsHandler)
+		account.GET("/me/version-retention", userHandlers.GetVersionRetentionHandler)
+		account.PUT("/me/version-retention", userHandlers.UpdateVersionRetentionHandler)
+		account.GET("/me/usage", userHandlers.GetUsageHandler)
+		account.GET("/me/image-metadata", userHandlers.GetImageMetadataSettingHandler)
+		account.PUT("/me/image-metadata", userHandlers.UpdateImageMetadataSettingHandler)
+		account.POST("/me/exports/:id/link", middleware.RequireRecentMFA(), userHandlers.ExportLinkHandler)
+		account.POST("/me/api-keys", middleware.RequireRecentMFA(), userHandlers.CreateAPIKeyHandler)
+		account.GET("/me/api-keys", userHandlers.ListAPIKeysHandler)
+		account.DELETE("/me/api-keys/:id", userHandlers.RevokeAPIKeyHandler)
+		account.POST("/me/2fa/enroll", userHandlers.BeginTOTPEnrollmentHandler)
+		account.POST("/me/2fa/confirm", userHandlers.ConfirmTOTPEnrollmentHandler)
+		account.POST("/me/2fa/verify", userHandlers.StepUpMFAHandler)
+		account.POST("/me/2fa/recovery-codes", middleware.RequireRecentMFA(), userHandlers.RegenerateRecoveryCodesHandler)
+		account.DELETE("/me/2fa", middleware.RequireRecentMFA(), userHandlers.DisableTOTPHandler)
+	}
+
+	admin := account.Group("/admin")
+	admin.Use(middleware.RequireRole(models.RoleAdmin, models.RoleAuditor))
+	{
+		admin.GET("/users", adminHandlers.ListUsersHandler)
+		admin.GET("/files/:id", adminHandlers.GetFileHandler)
+		admin.GET("/storage", adminHandlers.StorageUsageHandler)
+		admin.GET("/audit-logs", adminHandlers.ListAuditLogsHandler)
+	}
+
+	adminOnly := admin.Group("/")
+	adminOnly.Use(middleware.RequireRole(models.RoleAdmin))
+	{
+		adminOnly.PATCH("/users/:id/role", adminHandlers.UpdateUserRoleHandler)
+		adminOnly.POST("/users/:id/disable", adminHandlers.DisableUserHandler)
+		adminOnly.POST("/users/:id/enable", adminHandlers.EnableUserHandler)
+		adminOnly.PUT("/users/:id/quota", adminHandlers.UpdateUserQuotaHandler)
+		adminOnly.DELETE("/files/:id", adminHandlers.ForceDeleteFileHandler)
+		adminOnly.POST("/unlock-login", adminHandlers.UnlockLoginHandler)
 	}
 }
@@ -0,0 +1,86 @@
+package schemas
+
+import (
+	"encoding/json"
+
+	"github.com/google/uuid"
+)
+
+type UnlockLoginInput struct {
+	Email     string `json:"email"`
+	IPAddress string `json:"ip_address"`
+}
+
+type UpdateUserRoleInput struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// UpdateUserQuotaInput sets a user's quota in bytes. 0 is unlimited and null
+// returns the user to the default.
+type UpdateUserQuotaInput struct {
+	QuotaBytes *int64 `json:"quota_bytes"`
+}
+
+type AdminUserResponse struct {
+	ID               uuid.UUID `json:"id"`
+	Email            string    `json:"email"`
+	Role             string    `json:"role"`
+	EmailVerified    bool      `json:"email_verified"`
+	TwoFactorEnabled bool      `json:"two_factor_enabled"`
+	Disabled         bool      `json:"disabled"`
+	DisabledAt       string    `json:"disabled_at,omitempty"`
+	FileCount        int64     `json:"file_count"`
+	// StorageUsed and StorageQuota are the quota accounting: every stored
+	// version counts, and a quota of 0 is unlimited. StorageReserved is the
+	// part of StorageUsed held by uploads still in progress.
+	StorageUsed     int64  `json:"storage_used"`
+	StorageReserved int64  `json:"storage_reserved"`
+	StorageQuota    int64  `json:"storage_quota"`
+	CustomQuota     bool   `json:"custom_quota"`
+	CreatedAt       string `json:"created_at"`
+}
+
+type AdminFileResponse struct {
+	ID               uuid.UUID `json:"id"`
+	FileName         string    `json:"file_name"`
+	Size             int64     `json:"size"`
+	FileType         string    `json:"file_type"`
+	OwnerID          uuid.UUID `json:"owner_id"`
+	OwnerEmail       string    `json:"owner_email"`
+	CreatedAt        string    `json:"created_at"`
+	UpdatedAt        string    `json:"updated_at"`
+	AccessedAt       string    `json:"accessed_at"`
+	DeletedStatus    bool      `json:"deleted_status"`
+	ActiveShareLinks int64     `json:"active_share_links"`
+}
+
+type UserStorageUsage struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Email     string    `json:"email"`
+	FileCount int64     `json:"file_count"`
+	Bytes     int64     `json:"bytes"`
+}
+
+type TypeStorageUsage struct {
+	FileType  string `json:"file_type"`
+	FileCount int64  `json:"file_count"`
+	Bytes     int64  `json:"bytes"`
+}
+
+type StorageUsageResponse struct {
+	TotalFiles int64              `json:"total_files"`
+	TotalBytes int64              `json:"total_bytes"`
+	TopUsers   []UserStorageUsage `json:"top_users"`
+	ByType     []TypeStorageUsage `json:"by_type"`
+}
+
+type AuditLogResponse struct {
+	ID         uuid.UUID       `json:"id"`
+	ActorID    *uuid.UUID      `json:"actor_id"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"target_type"`
+	TargetID   string          `json:"target_id"`
+	IPAddress  string          `json:"ip_address"`
+	Details    json.RawMessage `json:"details,omitempty"`
+	CreatedAt  string          `json:"created_at"`
+}
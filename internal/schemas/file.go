@@ -7,18 +7,50 @@ import (
 )
 
 type UploadedFileResponse struct {
-	FileNames []string `json:"file_names"`
+	FileNames []string       `json:"file_names"`
+	Results   []UploadResult `json:"results"`
+}
+
+const (
+	UploadStatusAccepted = "accepted"
+	UploadStatusRejected = "rejected"
+	UploadStatusFailed   = "failed"
+)
+
+// UploadResult is the outcome for one file of an upload, in request order.
+// Rejected files broke an upload rule; failed ones hit a server error and may
+// be retried.
+type UploadResult struct {
+	FileName  string     `json:"file_name"`
+	FileID    *uuid.UUID `json:"file_id,omitempty"`
+	Status    string     `json:"status"`
+	ErrorCode string     `json:"error_code,omitempty"`
+	Error     string     `json:"error,omitempty"`
 }
 
 type FileResponse struct {
-	ID            uuid.UUID `json:"id"`
-	FileName      string    `json:"file_name"`
-	Size          int64     `json:"size"`
-	FileType      string    `json:"file_type"`
-	CreatedAt     string    `json:"created_at"`
-	UpdatedAt     string    `json:"updated_at"`
-	AccessedAt    string    `json:"accessed_at"`
-	DeletedStatus bool      `json:"deleted_status"`
+	ID               uuid.UUID         `json:"id"`
+	FileName         string            `json:"file_name"`
+	Size             int64             `json:"size"`
+	FileType         string            `json:"file_type"`
+	MimeType         string            `json:"mime_type,omitempty"`
+	TypeMismatch     bool              `json:"type_mismatch,omitempty"`
+	ImageWidth       int               `json:"image_width,omitempty"`
+	ImageHeight      int               `json:"image_height,omitempty"`
+	CapturedAt       string            `json:"captured_at,omitempty"`
+	MetadataStripped bool              `json:"metadata_stripped,omitempty"`
+	SHA256           string            `json:"sha256,omitempty"`
+	Corrupted        bool              `json:"corrupted,omitempty"`
+	ThumbnailURLs    map[string]string `json:"thumbnail_urls,omitempty"`
+	FolderID         *uuid.UUID        `json:"folder_id"`
+	Tags             []string          `json:"tags"`
+	Metadata         map[string]string `json:"metadata"`
+	Rank             float32           `json:"rank,omitempty"`
+	Snippet          string            `json:"snippet,omitempty"`
+	CreatedAt        string            `json:"created_at"`
+	UpdatedAt        string            `json:"updated_at"`
+	AccessedAt       string            `json:"accessed_at"`
+	DeletedStatus    bool              `json:"deleted_status"`
 }
 
 type FilesResponse struct {
@@ -33,3 +65,44 @@ type RenameFileResponse struct {
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
 }
+
+// UpdateTagsInput adds and removes tags on every listed file. Removals are
+// applied first, so a tag in both lists ends up present.
+type UpdateTagsInput struct {
+	FileIDs []uuid.UUID `json:"file_ids" binding:"required,min=1,max=500"`
+	Add     []string    `json:"add"`
+	Remove  []string    `json:"remove"`
+}
+
+// UpdateMetadataInput sets and removes metadata keys on every listed file.
+type UpdateMetadataInput struct {
+	FileIDs []uuid.UUID       `json:"file_ids" binding:"required,min=1,max=500"`
+	Set     map[string]string `json:"set"`
+	Remove  []string          `json:"remove"`
+}
+
+// TrashedFileResponse describes a file in the trash. PurgeAt is when it will
+// be deleted permanently.
+type TrashedFileResponse struct {
+	ID        uuid.UUID  `json:"id"`
+	FileName  string     `json:"file_name"`
+	Size      int64      `json:"size"`
+	FileType  string     `json:"file_type"`
+	FolderID  *uuid.UUID `json:"folder_id"`
+	CreatedAt string     `json:"created_at"`
+	DeletedAt string     `json:"deleted_at"`
+	PurgeAt   string     `json:"purge_at"`
+}
+
+type FileVersionResponse struct {
+	Version      int    `json:"version"`
+	FileName     string `json:"file_name"`
+	FileType     string `json:"file_type"`
+	Size         int64  `json:"size"`
+	MimeType     string `json:"mime_type,omitempty"`
+	SHA256       string `json:"sha256,omitempty"`
+	Corrupted    bool   `json:"corrupted,omitempty"`
+	Current      bool   `json:"current"`
+	RestoredFrom *int   `json:"restored_from,omitempty"`
+	CreatedAt    string `json:"created_at"`
+}
@@ -0,0 +1,44 @@
+package schemas
+
+import "github.com/google/uuid"
+
+type CreateFolderInput struct {
+	Name     string     `json:"name" binding:"required"`
+	ParentID *uuid.UUID `json:"parent_id"`
+}
+
+type RenameFolderInput struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// MoveFolderInput moves a folder to the root when ParentID is null.
+type MoveFolderInput struct {
+	ParentID *uuid.UUID `json:"parent_id"`
+}
+
+// MoveFileInput moves a file to the root when FolderID is null.
+type MoveFileInput struct {
+	FolderID *uuid.UUID `json:"folder_id"`
+}
+
+type FolderResponse struct {
+	ID        uuid.UUID  `json:"id"`
+	Name      string     `json:"name"`
+	ParentID  *uuid.UUID `json:"parent_id"`
+	CreatedAt string     `json:"created_at"`
+	UpdatedAt string     `json:"updated_at"`
+}
+
+type BreadcrumbResponse struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+}
+
+// FolderContentsResponse describes one level of the tree. Folder is nil and
+// Path empty for the root.
+type FolderContentsResponse struct {
+	Folder  *FolderResponse      `json:"folder"`
+	Path    []BreadcrumbResponse `json:"path"`
+	Folders []FolderResponse     `json:"folders"`
+	Files   []FileResponse       `json:"files"`
+}
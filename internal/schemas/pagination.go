@@ -0,0 +1,11 @@
+package schemas
+
+// PageInfo accompanies every cursor paginated list. Pass NextCursor or
+// PrevCursor back as the cursor parameter, with the same sort and order, to
+// fetch the adjacent page; they are omitted when there is no such page.
+type PageInfo struct {
+	Limit      int    `json:"limit"`
+	Total      int64  `json:"total"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
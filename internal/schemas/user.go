@@ -1,6 +1,10 @@
 package schemas
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type LoginInput struct {
 	Email    string `json:"email" binding:"required"`
@@ -8,8 +12,22 @@ type LoginInput struct {
 }
 
 type LoginResponse struct {
-	Email string `json:"email"`
-	Token string `json:"token"`
+	Email            string `json:"email"`
+	Token            string `json:"token"`
+	ExpiresAt        string `json:"expires_at"`
+	RefreshToken     string `json:"refresh_token"`
+	RefreshExpiresAt string `json:"refresh_expires_at"`
+}
+
+type RefreshTokenInput struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type TokenResponse struct {
+	Token            string `json:"token"`
+	ExpiresAt        string `json:"expires_at"`
+	RefreshToken     string `json:"refresh_token"`
+	RefreshExpiresAt string `json:"refresh_expires_at"`
 }
 
 type RegisterUserInput struct {
@@ -19,12 +37,142 @@ type RegisterUserInput struct {
 
 type UserSignupResponse struct {
 	ID    uuid.UUID `json:"id"`
-	Email string `json:"email"`
+	Email string    `json:"email"`
 }
 
 type GetCurrentUserResponse struct {
-	ID        uuid.UUID `json:"id"`
-	Email     string    `json:"email"`
-	CreatedAt string    `json:"created_at"`
-	UpdatedAt string    `json:"updated_at"`
+	ID               uuid.UUID `json:"id"`
+	Email            string    `json:"email"`
+	Role             string    `json:"role"`
+	EmailVerified    bool      `json:"email_verified"`
+	TwoFactorEnabled bool      `json:"two_factor_enabled"`
+	// DeletionScheduledAt is set while an account deletion is pending.
+	DeletionScheduledAt string `json:"deletion_scheduled_at,omitempty"`
+	CreatedAt           string `json:"created_at"`
+	UpdatedAt           string `json:"updated_at"`
+}
+
+type SessionResponse struct {
+	ID         uuid.UUID `json:"id"`
+	IPAddress  string    `json:"ip_address"`
+	UserAgent  string    `json:"user_agent"`
+	CreatedAt  string    `json:"created_at"`
+	LastUsedAt string    `json:"last_used_at"`
+	ExpiresAt  string    `json:"expires_at"`
+	Current    bool      `json:"current"`
+}
+
+type CreateAPIKeyInput struct {
+	Name      string     `json:"name" binding:"required"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type APIKeyResponse struct {
+	ID         uuid.UUID `json:"id"`
+	Name       string    `json:"name"`
+	Prefix     string    `json:"prefix"`
+	Scopes     []string  `json:"scopes"`
+	CreatedAt  string    `json:"created_at"`
+	ExpiresAt  string    `json:"expires_at,omitempty"`
+	LastUsedAt string    `json:"last_used_at,omitempty"`
+}
+
+type CreatedAPIKeyResponse struct {
+	APIKeyResponse
+	Key string `json:"key"`
+}
+
+type PasswordResetRequestInput struct {
+	Email string `json:"email" binding:"required"`
+}
+
+type PasswordResetConfirmInput struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+type MFAChallengeResponse struct {
+	MFARequired    bool   `json:"mfa_required"`
+	ChallengeToken string `json:"challenge_token"`
+	ExpiresAt      string `json:"expires_at"`
+}
+
+type MFALoginInput struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code"`
+	RecoveryCode   string `json:"recovery_code"`
+}
+
+type TOTPCodeInput struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type TOTPEnrollmentResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type ChangePasswordInput struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+type DeleteAccountInput struct {
+	Password string `json:"password" binding:"required"`
+}
+
+type DataExportResponse struct {
+	ID          uuid.UUID `json:"id"`
+	Status      string    `json:"status"`
+	Size        int64     `json:"size,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	CreatedAt   string    `json:"created_at"`
+	CompletedAt string    `json:"completed_at,omitempty"`
+	ExpiresAt   string    `json:"expires_at,omitempty"`
+}
+
+type ExportLinkResponse struct {
+	DownloadURL string `json:"download_url"`
+	ExpiresAt   string `json:"expires_at"`
+}
+
+// VersionRetentionInput sets how many old file versions are kept: Mode
+// "count" keeps the newest Value versions of each file, "days" keeps old
+// versions for Value days, and an empty Mode restores the server default.
+type VersionRetentionInput struct {
+	Mode  string `json:"mode"`
+	Value int    `json:"value"`
+}
+
+type VersionRetentionResponse struct {
+	Mode  string `json:"mode"`
+	Value int    `json:"value"`
+}
+
+// ImageMetadataSettingInput turns stripping of image metadata from uploads
+// on or off.
+type ImageMetadataSettingInput struct {
+	Strip *bool `json:"strip" binding:"required"`
+}
+
+type ImageMetadataSettingResponse struct {
+	Strip bool `json:"strip"`
+}
+
+// UsageResponse is a user's storage usage. Every stored version counts,
+// including files in the trash. A QuotaBytes of 0 means unlimited, in which
+// case AvailableBytes is left out.
+type UsageResponse struct {
+	UsedBytes      int64              `json:"used_bytes"`
+	QuotaBytes     int64              `json:"quota_bytes"`
+	AvailableBytes *int64             `json:"available_bytes,omitempty"`
+	FileCount      int64              `json:"file_count"`
+	TrashBytes     int64              `json:"trash_bytes"`
+	VersionBytes   int64              `json:"version_bytes"`
+	ByType         []TypeStorageUsage `json:"by_type"`
 }
@@ -0,0 +1,421 @@
+package search
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxTextLength caps the extracted text kept per file. Postgres limits a
+// tsvector to 1MB, and the first half megabyte of a document is plenty to
+// find it by.
+const maxTextLength = 512 * 1024
+
+// extractorNone marks files whose format carries no text to index.
+const extractorNone = "none"
+
+type extractor func(data []byte) (string, error)
+
+var extractors = map[string]extractor{
+	"txt":      extractPlainText,
+	"text":     extractPlainText,
+	"log":      extractPlainText,
+	"md":       extractPlainText,
+	"markdown": extractPlainText,
+	"csv":      extractDelimited(','),
+	"tsv":      extractDelimited('\t'),
+	"json":     extractJSON,
+	"docx":     extractDOCX,
+	"pdf":      extractPDF,
+}
+
+// Extract returns the searchable text of a file along with the name of the
+// extractor used. Unsupported types yield no text and extractorNone.
+func Extract(fileType string, data []byte) (string, string, error) {
+	fileType = strings.ToLower(fileType)
+	extract, ok := extractors[fileType]
+	if !ok {
+		return "", extractorNone, nil
+	}
+
+	text, err := extract(data)
+	if err != nil {
+		return "", fileType, err
+	}
+	return cleanText(text), fileType, nil
+}
+
+// cleanText makes the text safe to store in Postgres, which rejects NUL bytes
+// and invalid UTF-8, and truncates it on a rune boundary.
+func cleanText(text string) string {
+	text = strings.ToValidUTF8(text, " ")
+	text = strings.ReplaceAll(text, "\x00", " ")
+	if len(text) > maxTextLength {
+		text = text[:maxTextLength]
+		for !utf8.ValidString(text) {
+			text = text[:len(text)-1]
+		}
+	}
+	return strings.TrimSpace(text)
+}
+
+// extractPlainText also serves Markdown: the tsvector parser drops the markup
+// punctuation on its own.
+func extractPlainText(data []byte) (string, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	return string(data), nil
+}
+
+func extractDelimited(comma rune) extractor {
+	return func(data []byte) (string, error) {
+		reader := csv.NewReader(bytes.NewReader(data))
+		reader.Comma = comma
+		reader.FieldsPerRecord = -1
+		reader.LazyQuotes = true
+
+		var text strings.Builder
+		for text.Len() < maxTextLength {
+			record, err := reader.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				// Not well formed enough for the CSV reader, so index the raw text.
+				return extractPlainText(data)
+			}
+			text.WriteString(strings.Join(record, " "))
+			text.WriteByte('\n')
+		}
+		return text.String(), nil
+	}
+}
+
+// extractJSON indexes every object key and scalar value in the document.
+func extractJSON(data []byte) (string, error) {
+	var document interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&document); err != nil {
+		return extractPlainText(data)
+	}
+
+	var text strings.Builder
+	var walk func(value interface{})
+	walk = func(value interface{}) {
+		if text.Len() >= maxTextLength {
+			return
+		}
+		switch v := value.(type) {
+		case map[string]interface{}:
+			keys := make([]string, 0, len(v))
+			for key := range v {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				text.WriteString(key)
+				text.WriteByte(' ')
+				walk(v[key])
+			}
+		case []interface{}:
+			for _, item := range v {
+				walk(item)
+			}
+		case string:
+			text.WriteString(v)
+			text.WriteByte('\n')
+		case json.Number:
+			text.WriteString(v.String())
+			text.WriteByte(' ')
+		case bool:
+			text.WriteString(strconv.FormatBool(v))
+			text.WriteByte(' ')
+		}
+	}
+	walk(document)
+	return text.String(), nil
+}
+
+// extractDOCX reads the text runs of the main document part, keeping
+// paragraph and line breaks.
+func extractDOCX(data []byte) (string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("failed to open docx archive: %v", err)
+	}
+
+	for _, entry := range archive.File {
+		if entry.Name != "word/document.xml" {
+			continue
+		}
+
+		part, err := entry.Open()
+		if err != nil {
+			return "", fmt.Errorf("failed to open docx document: %v", err)
+		}
+		defer part.Close()
+
+		var text strings.Builder
+		inText := false
+		decoder := xml.NewDecoder(io.LimitReader(part, 50*1024*1024))
+		for text.Len() < maxTextLength {
+			token, err := decoder.Token()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return "", fmt.Errorf("failed to parse docx document: %v", err)
+			}
+
+			switch t := token.(type) {
+			case xml.StartElement:
+				switch t.Name.Local {
+				case "t":
+					inText = true
+				case "tab":
+					text.WriteByte('\t')
+				case "br", "cr":
+					text.WriteByte('\n')
+				}
+			case xml.EndElement:
+				switch t.Name.Local {
+				case "t":
+					inText = false
+				case "p":
+					text.WriteByte('\n')
+				}
+			case xml.CharData:
+				if inText {
+					text.Write(t)
+				}
+			}
+		}
+		return text.String(), nil
+	}
+
+	return "", fmt.Errorf("docx archive has no word/document.xml")
+}
+
+var pdfStream = regexp.MustCompile(`(?s)<<(.{0,1000}?)>>\s*stream\r?\n`)
+
+// extractPDF pulls the text layer out of a PDF's content streams. It handles
+// uncompressed and Flate compressed streams and fonts with single byte
+// encodings, which covers most PDFs produced by office software. Scanned PDFs
+// have no text layer and yield nothing.
+func extractPDF(data []byte) (string, error) {
+	if !bytes.HasPrefix(data, []byte("%PDF")) {
+		return "", fmt.Errorf("not a PDF file")
+	}
+
+	var text strings.Builder
+	for _, match := range pdfStream.FindAllSubmatchIndex(data, -1) {
+		if text.Len() >= maxTextLength {
+			break
+		}
+
+		dictionary := data[match[2]:match[3]]
+		start := match[1]
+		end := bytes.Index(data[start:], []byte("endstream"))
+		if end < 0 {
+			break
+		}
+		stream := data[start : start+end]
+
+		if bytes.Contains(dictionary, []byte("/FlateDecode")) {
+			reader, err := zlib.NewReader(bytes.NewReader(stream))
+			if err != nil {
+				continue
+			}
+			// Truncated or slightly damaged streams still yield a usable prefix.
+			stream, _ = io.ReadAll(io.LimitReader(reader, 16*1024*1024))
+			reader.Close()
+		} else if bytes.Contains(dictionary, []byte("/Filter")) {
+			// Images and other encodings carry no text.
+			continue
+		}
+
+		if !bytes.Contains(stream, []byte("BT")) {
+			continue
+		}
+		pdfContentText(stream, &text)
+	}
+
+	return text.String(), nil
+}
+
+// pdfContentText walks the operators of a content stream and writes the
+// strings shown by Tj, TJ, ' and ".
+func pdfContentText(stream []byte, text *strings.Builder) {
+	var operands [][]byte
+	inText := false
+
+	for i := 0; i < len(stream); {
+		c := stream[i]
+		switch {
+		case c == '(':
+			value, next := pdfLiteralString(stream, i)
+			operands = append(operands, value)
+			i = next
+		case c == '<' && i+1 < len(stream) && stream[i+1] != '<':
+			value, next := pdfHexString(stream, i)
+			operands = append(operands, value)
+			i = next
+		case c == '[' || c == ']':
+			i++
+		case c == '%':
+			for i < len(stream) && stream[i] != '\n' && stream[i] != '\r' {
+				i++
+			}
+		case isPDFDelimiter(c):
+			i++
+		default:
+			start := i
+			for i < len(stream) && !isPDFDelimiter(stream[i]) && stream[i] != '(' && stream[i] != '<' &&
+				stream[i] != '[' && stream[i] != ']' && stream[i] != '%' {
+				i++
+			}
+			if i == start {
+				i++
+				continue
+			}
+			word := string(stream[start:i])
+
+			// Numbers and names are operands we do not need.
+			if word[0] == '/' || word[0] == '-' || word[0] == '+' || word[0] == '.' || (word[0] >= '0' && word[0] <= '9') {
+				continue
+			}
+
+			switch word {
+			case "BT":
+				inText = true
+			case "ET":
+				inText = false
+				text.WriteByte('\n')
+			case "Tj", "TJ":
+				if inText {
+					for _, operand := range operands {
+						text.Write(operand)
+					}
+					text.WriteByte(' ')
+				}
+			case "'", "\"":
+				if inText {
+					text.WriteByte('\n')
+					for _, operand := range operands {
+						text.Write(operand)
+					}
+				}
+			case "Td", "TD", "T*":
+				if inText {
+					text.WriteByte('\n')
+				}
+			}
+			operands = operands[:0]
+		}
+	}
+}
+
+func isPDFDelimiter(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0 || c == '{' || c == '}' || c == '>' || c == ')'
+}
+
+func pdfLiteralString(stream []byte, i int) ([]byte, int) {
+	var value []byte
+	depth := 0
+	for i++; i < len(stream); i++ {
+		c := stream[i]
+		switch c {
+		case '\\':
+			i++
+			if i >= len(stream) {
+				return value, i
+			}
+			switch e := stream[i]; e {
+			case 'n', 'r':
+				value = append(value, '\n')
+			case 't':
+				value = append(value, '\t')
+			case 'b', 'f':
+			case '\r', '\n':
+				// Line continuation.
+			default:
+				if e >= '0' && e <= '7' {
+					octal := int(e - '0')
+					for n := 0; n < 2 && i+1 < len(stream) && stream[i+1] >= '0' && stream[i+1] <= '7'; n++ {
+						i++
+						octal = octal*8 + int(stream[i]-'0')
+					}
+					value = appendPDFByte(value, byte(octal))
+				} else {
+					value = append(value, e)
+				}
+			}
+		case '(':
+			depth++
+			value = append(value, c)
+		case ')':
+			if depth == 0 {
+				return value, i + 1
+			}
+			depth--
+			value = append(value, c)
+		default:
+			value = appendPDFByte(value, c)
+		}
+	}
+	return value, i
+}
+
+func pdfHexString(stream []byte, i int) ([]byte, int) {
+	end := bytes.IndexByte(stream[i:], '>')
+	if end < 0 {
+		return nil, len(stream)
+	}
+
+	var digits []byte
+	for _, c := range stream[i+1 : i+end] {
+		if (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F') {
+			digits = append(digits, c)
+		}
+	}
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+
+	var raw []byte
+	for n := 0; n < len(digits); n += 2 {
+		b, _ := strconv.ParseUint(string(digits[n:n+2]), 16, 8)
+		raw = append(raw, byte(b))
+	}
+
+	var value []byte
+	for _, b := range raw {
+		value = appendPDFByte(value, b)
+	}
+	return value, i + end + 1
+}
+
+// appendPDFByte decodes a byte of a single byte encoded font as Latin-1,
+// which matches WinAnsi and PDFDocEncoding for letters. Control bytes, common
+// in two byte CID fonts we cannot map without the font's CMap, are dropped.
+func appendPDFByte(value []byte, b byte) []byte {
+	switch {
+	case b >= 0x20 && b < 0x7f:
+		return append(value, b)
+	case b >= 0xa0:
+		return utf8.AppendRune(value, rune(b))
+	case b == '\n' || b == '\t':
+		return append(value, b)
+	}
+	return value
+}
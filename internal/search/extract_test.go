@@ -0,0 +1,107 @@
+package search
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"strings"
+	"testing"
+)
+
+func docx(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := archive.Create(name)
+		if err != nil {
+			t.Fatalf("failed to add %s: %v", name, err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatalf("failed to close archive: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func deflate(data string) string {
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	w.Write([]byte(data))
+	w.Close()
+	return buf.String()
+}
+
+func TestExtractDOCX(t *testing.T) {
+	const body = `<w:document xmlns:w="w"><w:body><w:p><w:r><w:t>Hello</w:t><w:tab/><w:t>world</w:t></w:r></w:p></w:body></w:document>`
+	valid := docx(t, map[string]string{"word/document.xml": body})
+
+	tests := []struct {
+		name    string
+		data    []byte
+		want    string
+		wantErr bool
+	}{
+		{name: "valid", data: valid, want: "Hello\tworld"},
+		{name: "empty", data: nil, wantErr: true},
+		{name: "not a zip", data: []byte("PK\x03\x04 garbage"), wantErr: true},
+		{name: "truncated archive", data: valid[:len(valid)/2], wantErr: true},
+		{name: "missing document part", data: docx(t, map[string]string{"word/styles.xml": "<w:styles/>"}), wantErr: true},
+		{name: "malformed xml", data: docx(t, map[string]string{"word/document.xml": "<w:document><w:t>Hello</w:p>"}), wantErr: true},
+		{name: "unclosed xml", data: docx(t, map[string]string{"word/document.xml": "<w:document><w:t>Hello"}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, extractor, err := Extract("docx", tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if extractor != "docx" {
+				t.Errorf("got extractor %q, want docx", extractor)
+			}
+			if text != tt.want {
+				t.Errorf("got text %q, want %q", text, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractPDF(t *testing.T) {
+	content := "BT /F1 12 Tf 72 712 Td (Hello \\(PDF\\)) Tj ET"
+	compressed := deflate(content)
+
+	tests := []struct {
+		name    string
+		data    string
+		want    string
+		wantErr bool
+	}{
+		{name: "plain stream", data: "%PDF-1.4\n<< /Length 44 >>\nstream\n" + content + "\nendstream", want: "Hello (PDF)"},
+		{name: "flate stream", data: "%PDF-1.4\n<< /Filter /FlateDecode >>\nstream\n" + compressed + "\nendstream", want: "Hello (PDF)"},
+		{name: "not a pdf", data: "<html>", wantErr: true},
+		{name: "empty", data: "", wantErr: true},
+		{name: "header only", data: "%PDF-1.7"},
+		{name: "missing endstream", data: "%PDF-1.4\n<< >>\nstream\n" + content},
+		{name: "corrupt flate stream", data: "%PDF-1.4\n<< /Filter /FlateDecode >>\nstream\nnot zlib\nendstream"},
+		{name: "truncated flate stream", data: "%PDF-1.4\n<< /Filter /FlateDecode >>\nstream\n" + compressed[:len(compressed)/2] + "\nendstream"},
+		{name: "image stream", data: "%PDF-1.4\n<< /Filter /DCTDecode >>\nstream\nBT (jpeg) Tj ET\nendstream"},
+		{name: "unterminated string", data: "%PDF-1.4\n<< >>\nstream\nBT (Hello\nendstream"},
+		{name: "unterminated hex string", data: "%PDF-1.4\n<< >>\nstream\nBT <48656c\nendstream"},
+		{name: "dangling escape", data: "%PDF-1.4\n<< >>\nstream\nBT (Hi\\\nendstream"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, _, err := Extract("pdf", []byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if !strings.Contains(text, tt.want) {
+				t.Errorf("got text %q, want it to contain %q", text, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package search
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/souvik150/file-sharing-app/internal/config"
+	"github.com/souvik150/file-sharing-app/internal/database"
+	"github.com/souvik150/file-sharing-app/internal/models"
+	"github.com/souvik150/file-sharing-app/pkg/s3"
+)
+
+// pendingBatchSize bounds how many files one run of IndexPending handles.
+const pendingBatchSize = 100
+
+// extractorUnavailable marks files whose object could not be downloaded.
+const extractorUnavailable = "unavailable"
+
+// uploadGracePeriod keeps IndexPending away from files whose upload to S3 may
+// still be in progress; those are indexed when the upload completes.
+const uploadGracePeriod = 10 * time.Minute
+
+// Refresh rebuilds the search vector of the files from their name, tags and
+// extracted text. Names weigh most, then tags, then content. It must run
+// whenever one of those changes.
+func Refresh(db *gorm.DB, fileIDs []uuid.UUID) error {
+	if len(fileIDs) == 0 {
+		return nil
+	}
+
+	err := db.Exec(`UPDATE files SET search_vector =
+		setweight(to_tsvector('english', regexp_replace(files.file_name, '[._-]+', ' ', 'g')), 'A') ||
+		setweight(to_tsvector('english', COALESCE(
+			(SELECT string_agg(tag, ' ') FROM file_tags WHERE file_tags.file_id = files.id), '')), 'B') ||
+		setweight(to_tsvector('english', COALESCE(
+			(SELECT text FROM file_contents WHERE file_contents.file_id = files.id), '')), 'C')
+		WHERE files.id IN ?`, fileIDs).Error
+	if err != nil {
+		return fmt.Errorf("failed to refresh search index: %v", err)
+	}
+	return nil
+}
+
+// IndexFile downloads a file, extracts its text and refreshes its search
+// vector. Files above SEARCH_INDEX_MAX_FILE_SIZE are indexed by name and tags
+// only.
+func IndexFile(file *models.File) error {
+	content := models.FileContent{FileID: file.ID, Extractor: extractorNone, IndexedAt: time.Now()}
+
+	var downloadErr error
+	if _, supported := extractors[strings.ToLower(file.FileType)]; supported && file.Size <= config.AppConfig.SearchIndexMaxFileSize {
+		data, err := s3.DownloadFile(file.StorageKey())
+		if err != nil {
+			// Still index the name and tags, and keep the job from retrying a
+			// missing object on every run.
+			downloadErr = fmt.Errorf("failed to download file %s: %v", file.ID, err)
+			content.Extractor = extractorUnavailable
+		} else if text, extractor, err := Extract(file.FileType, data); err != nil {
+			// Damaged documents are recorded as having no text rather than
+			// retried forever.
+			log.Printf("⚠️ Could not extract text from file %s: %v", file.ID, err)
+		} else {
+			content.Text = text
+			content.Extractor = extractor
+		}
+	}
+
+	db := database.GetDB()
+	err := db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&content).Error
+	if err != nil {
+		return fmt.Errorf("failed to store text of file %s: %v", file.ID, err)
+	}
+
+	if err := Refresh(db, []uuid.UUID{file.ID}); err != nil {
+		return err
+	}
+	return downloadErr
+}
+
+// IndexFiles indexes freshly uploaded files by ID, logging failures.
+func IndexFiles(fileIDs []string) {
+	var uploaded []models.File
+	if err := database.GetDB().Where("id IN ?", fileIDs).Find(&uploaded).Error; err != nil {
+		log.Printf("❌ Error loading files to index: %v", err)
+		return
+	}
+
+	for i := range uploaded {
+		if err := IndexFile(&uploaded[i]); err != nil {
+			log.Printf("❌ Error indexing file %s: %v", uploaded[i].ID, err)
+			continue
+		}
+		log.Printf("🔎 Indexed file %s", uploaded[i].ID)
+	}
+}
+
+// IndexPending indexes live files that have no extracted text yet, such as
+// files uploaded before search existed or whose indexing failed.
+func IndexPending() {
+	var pending []models.File
+	err := database.GetDB().
+		Where("created_at < ?", time.Now().Add(-uploadGracePeriod)).
+		Where("NOT EXISTS (SELECT 1 FROM file_contents WHERE file_contents.file_id = files.id)").
+		Order("created_at").
+		Limit(pendingBatchSize).
+		Find(&pending).Error
+	if err != nil {
+		log.Printf("❌ Error loading files to index: %v", err)
+		return
+	}
+
+	for i := range pending {
+		if err := IndexFile(&pending[i]); err != nil {
+			log.Printf("❌ Error indexing file %s: %v", pending[i].ID, err)
+		}
+	}
+
+	if len(pending) > 0 {
+		log.Printf("🔎 Indexing job processed %d files", len(pending))
+	}
+}
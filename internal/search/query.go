@@ -0,0 +1,81 @@
+package search
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/souvik150/file-sharing-app/internal/database"
+)
+
+// startSel and stopSel delimit matched terms in ts_headline output. They are
+// control characters stripped from the source text, so they cannot be forged
+// by a file name or document.
+const (
+	startSel = "\x01"
+	stopSel  = "\x02"
+)
+
+// Match is the ranking and highlighted snippet of a search hit. The snippet
+// is safe HTML: the source text is escaped and matched terms are wrapped in
+// <mark> tags.
+type Match struct {
+	FileID  uuid.UUID
+	Rank    float32
+	Snippet string
+}
+
+// Matching limits a query on files to those matching a web search style
+// query ("quoted phrases", or, -excluded). Rank ordering is left to the
+// caller.
+func Matching(query string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("files.search_vector @@ websearch_to_tsquery('english', ?)", query)
+	}
+}
+
+// Highlight computes the rank and snippet of each file for the query. The
+// snippet comes from the extracted text, or the file name when the text does
+// not match.
+func Highlight(fileIDs []uuid.UUID, query string) (map[uuid.UUID]Match, error) {
+	matches := make(map[uuid.UUID]Match)
+	if len(fileIDs) == 0 {
+		return matches, nil
+	}
+
+	selectors := "StartSel=" + startSel + ", StopSel=" + stopSel
+	var rows []Match
+	err := database.GetDB().Raw(`
+		WITH q AS (SELECT websearch_to_tsquery('english', ?) AS query)
+		SELECT files.id AS file_id,
+			ts_rank_cd(files.search_vector, q.query) AS rank,
+			CASE WHEN to_tsvector('english', COALESCE(file_contents.text, '')) @@ q.query
+				THEN ts_headline('english', translate(file_contents.text, ?, ''), q.query,
+					? || ', MaxWords=30, MinWords=10, MaxFragments=2')
+				ELSE ts_headline('english', translate(files.file_name, ?, ''), q.query, ?)
+			END AS snippet
+		FROM files CROSS JOIN q
+		LEFT JOIN file_contents ON file_contents.file_id = files.id
+		WHERE files.id IN ?`,
+		query, startSel+stopSel, selectors, startSel+stopSel, selectors, fileIDs).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to highlight search results: %v", err)
+	}
+
+	for _, row := range rows {
+		row.Snippet = markSnippet(row.Snippet)
+		matches[row.FileID] = row
+	}
+	return matches, nil
+}
+
+// markSnippet escapes a ts_headline snippet for HTML and turns the selectors
+// around matched terms into <mark> tags.
+func markSnippet(snippet string) string {
+	escaped := html.EscapeString(snippet)
+	return strings.NewReplacer(startSel, "<mark>", stopSel, "</mark>").Replace(escaped)
+}
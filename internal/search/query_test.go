@@ -0,0 +1,25 @@
+package search
+
+import "testing"
+
+func TestMarkSnippet(t *testing.T) {
+	tests := []struct {
+		name    string
+		snippet string
+		want    string
+	}{
+		{name: "plain", snippet: "quarterly report", want: "quarterly report"},
+		{name: "match", snippet: "the \x01quarterly\x02 report", want: "the <mark>quarterly</mark> report"},
+		{name: "several matches", snippet: "\x01a\x02 and \x01b\x02", want: "<mark>a</mark> and <mark>b</mark>"},
+		{name: "markup in the source", snippet: "<img src=x onerror=alert(1)> \x01report\x02", want: "&lt;img src=x onerror=alert(1)&gt; <mark>report</mark>"},
+		{name: "forged mark tags", snippet: "<mark>x</mark> & \"y\"", want: "&lt;mark&gt;x&lt;/mark&gt; &amp; &#34;y&#34;"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := markSnippet(tt.snippet); got != tt.want {
+				t.Errorf("markSnippet(%q) = %q, want %q", tt.snippet, got, tt.want)
+			}
+		})
+	}
+}
@@ -8,11 +8,13 @@ import (
 
 	"github.com/gorilla/websocket"
 
+	"github.com/souvik150/file-sharing-app/internal/auth"
 	"github.com/souvik150/file-sharing-app/internal/cache"
-	"github.com/souvik150/file-sharing-app/pkg/middleware"
 )
 
-var connectedClients = make(map[string]*websocket.Conn)
+// connectedClients maps a user ID to that user's open connections, each
+// tagged with the session it was authenticated with.
+var connectedClients = make(map[string]map[*websocket.Conn]string)
 var clientsMutex sync.Mutex
 
 var upgrader = websocket.Upgrader{
@@ -35,15 +37,19 @@ func HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID, err := middleware.ExtractUserIDFromToken(token)
+	claims, err := auth.ParseAccessToken(token)
 	if err != nil {
 		log.Printf("❌ Invalid token: %v", err)
 		conn.Close()
 		return
 	}
+	userID := claims.UserID
 
 	clientsMutex.Lock()
-	connectedClients[userID] = conn
+	if connectedClients[userID] == nil {
+		connectedClients[userID] = make(map[*websocket.Conn]string)
+	}
+	connectedClients[userID][conn] = claims.FamilyID
 	clientsMutex.Unlock()
 
 	if err := addClientToRedis(userID); err != nil {
@@ -56,10 +62,16 @@ func HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 
 	defer func() {
 		conn.Close()
-		removeClientFromRedis(userID)
 		clientsMutex.Lock()
-		delete(connectedClients, userID)
+		delete(connectedClients[userID], conn)
+		lastConnection := len(connectedClients[userID]) == 0
+		if lastConnection {
+			delete(connectedClients, userID)
+		}
 		clientsMutex.Unlock()
+		if lastConnection {
+			removeClientFromRedis(userID)
+		}
 		log.Printf("🔴 User disconnected. User ID: %s", userID)
 	}()
 
@@ -72,6 +84,39 @@ func HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// ListenForSessionRevocations closes WebSocket connections whose session has
+// been revoked, on whichever instance they happen to be connected to.
+func ListenForSessionRevocations() {
+	pubsub := cache.GetClient().Subscribe(cache.Ctx, auth.SessionRevokedChannel)
+	log.Println("Listening for session revocations")
+
+	go func() {
+		for msg := range pubsub.Channel() {
+			closeSession(msg.Payload)
+		}
+	}()
+}
+
+func closeSession(sessionID string) {
+	clientsMutex.Lock()
+	defer clientsMutex.Unlock()
+
+	for userID, conns := range connectedClients {
+		for conn, connSessionID := range conns {
+			if connSessionID != sessionID {
+				continue
+			}
+
+			message := websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "session revoked")
+			if err := conn.WriteMessage(websocket.CloseMessage, message); err != nil {
+				log.Printf("⚠️ Error sending close message to user %s: %v", userID, err)
+			}
+			conn.Close()
+			log.Printf("🔒 Closed WebSocket of revoked session %s for user %s", sessionID, userID)
+		}
+	}
+}
+
 func addClientToRedis(userID string) error {
 	ctx := context.Background()
 	redisClient := cache.GetClient()
@@ -97,15 +142,18 @@ func NotifyUser(userID, message string) {
 	clientsMutex.Lock()
 	defer clientsMutex.Unlock()
 
-	conn, exists := connectedClients[userID]
-	if exists {
+	conns, exists := connectedClients[userID]
+	if !exists {
+		log.Printf("🔴 No active WebSocket connection for user %s", userID)
+		return
+	}
+
+	for conn := range conns {
 		err := conn.WriteMessage(websocket.TextMessage, []byte(message))
 		if err != nil {
 			log.Printf("⚠️ Error sending WebSocket message to user %s: %v", userID, err)
 		} else {
 			log.Printf("📨 Sent WebSocket notification to user %s", userID)
 		}
-	} else {
-		log.Printf("🔴 No active WebSocket connection for user %s", userID)
 	}
 }
@@ -0,0 +1,46 @@
+package mailer
+
+import (
+	"log"
+
+	appConfig "github.com/souvik150/file-sharing-app/internal/config"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers transactional email. Implementations must be safe for
+// concurrent use.
+type Mailer interface {
+	Send(message Message) error
+}
+
+var defaultMailer Mailer
+
+// Setup picks the mailer named by MAIL_DRIVER: "smtp" for real delivery or
+// "outbox" to write messages to MAIL_OUTBOX_DIR for local development.
+func Setup() {
+	switch appConfig.AppConfig.MailDriver {
+	case "smtp":
+		defaultMailer = NewSMTPMailer(
+			appConfig.AppConfig.SMTPHost,
+			appConfig.AppConfig.SMTPPort,
+			appConfig.AppConfig.SMTPUsername,
+			appConfig.AppConfig.SMTPPassword,
+			appConfig.AppConfig.MailFrom,
+		)
+	case "outbox":
+		defaultMailer = NewOutboxMailer(appConfig.AppConfig.MailOutboxDir, appConfig.AppConfig.MailFrom)
+	default:
+		log.Fatalf("Unknown MAIL_DRIVER %q", appConfig.AppConfig.MailDriver)
+	}
+
+	log.Printf("✉️ Mailer configured with %s driver", appConfig.AppConfig.MailDriver)
+}
+
+func Send(message Message) error {
+	return defaultMailer.Send(message)
+}
@@ -0,0 +1,37 @@
+package mailer
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OutboxMailer writes every message to a .eml file instead of delivering it,
+// which makes verification and reset links easy to follow in development.
+type OutboxMailer struct {
+	dir  string
+	from string
+}
+
+func NewOutboxMailer(dir, from string) *OutboxMailer {
+	return &OutboxMailer{dir: dir, from: from}
+}
+
+func (m *OutboxMailer) Send(message Message) error {
+	if err := os.MkdirAll(m.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create outbox directory: %v", err)
+	}
+
+	fileName := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102T150405"), uuid.New().String())
+	path := filepath.Join(m.dir, fileName)
+	if err := os.WriteFile(path, formatMessage(m.from, message), 0644); err != nil {
+		return fmt.Errorf("failed to write email to outbox: %v", err)
+	}
+
+	log.Printf("📬 Email to %s (%q) written to %s", message.To, message.Subject, path)
+	return nil
+}
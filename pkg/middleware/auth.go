@@ -1,18 +1,19 @@
 package middleware
 
 import (
+	"errors"
 	"log"
 	"net/http"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
 	"golang.org/x/time/rate"
 
-	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
 
-	appConfig "github.com/souvik150/file-sharing-app/internal/config"
+	"github.com/souvik150/file-sharing-app/internal/auth"
 )
 
 var (
@@ -31,27 +32,138 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		tokenString := strings.Split(authHeader, "Bearer ")[1]
-		jwtSecretKey := []byte(appConfig.AppConfig.EncryptionKey)
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			return jwtSecretKey, nil
-		})
+		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+
+		var userID string
+		if auth.IsAPIKey(tokenString) {
+			apiKey, err := auth.AuthenticateAPIKey(tokenString)
+			if err != nil {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+				c.Abort()
+				return
+			}
+
+			userID = apiKey.UserID.String()
+			c.Set("authType", "api_key")
+			c.Set("apiKeyID", apiKey.ID.String())
+			c.Set("scopes", auth.APIKeyScopes(apiKey))
+		} else {
+			claims, err := auth.ParseAccessToken(tokenString)
+			if err != nil {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+				c.Abort()
+				return
+			}
+
+			userID = claims.UserID
+			c.Set("authType", "session")
+			c.Set("tokenClaims", claims)
+			if claims.Scopes != nil {
+				c.Set("scopes", claims.Scopes)
+			} else {
+				c.Set("scopes", auth.AllScopes)
+			}
+			auth.TouchSession(claims.FamilyID)
+		}
+
+		log.Println("Authenticated user:", userID)
+		c.Set("userID", userID)
+
+		if !rateLimitMiddleware(c, userID) {
+			return
+		}
 
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		c.Next()
+	}
+}
+
+// RequireScope rejects requests whose credential was not granted scope.
+// Interactive sessions carry every scope, so this only narrows API keys.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scopes, _ := c.Get("scopes")
+		granted, _ := scopes.([]string)
+		if slices.Contains(granted, scope) {
+			c.Next()
+			return
+		}
+
+		c.JSON(http.StatusForbidden, gin.H{"error": "Missing required scope: " + scope})
+		c.Abort()
+	}
+}
+
+// RequireSession restricts a route to interactive logins, keeping account
+// management such as sessions and API keys out of reach of API keys.
+func RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("authType") != "session" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "This action requires an interactive login"})
 			c.Abort()
 			return
 		}
 
-		claims := token.Claims.(jwt.MapClaims)
-		userID := claims["userID"]
-		log.Println("Authenticated user:", userID)
-		c.Set("userID", userID)
+		c.Next()
+	}
+}
 
-		if !rateLimitMiddleware(c, userID.(string)) {
+// RequireRecentMFA guards sensitive actions: users with two-factor
+// authentication enabled must have presented a second factor in this session
+// recently, otherwise they are asked to step up via /me/2fa/verify.
+func RequireRecentMFA() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, _ := c.Get("tokenClaims")
+		claims, ok := value.(*auth.AccessClaims)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "This action requires an interactive login"})
+			c.Abort()
 			return
 		}
 
+		recent, err := auth.HasRecentMFA(claims)
+		if err != nil {
+			log.Printf("Error checking second factor: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check second factor"})
+			c.Abort()
+			return
+		}
+
+		if !recent {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":        "This action requires a recent second factor",
+				"mfa_required": true,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireRole restricts a route to users holding one of the given roles.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role, err := auth.UserRole(c.GetString("userID"))
+		if errors.Is(err, auth.ErrAccountDisabled) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Account is disabled"})
+			c.Abort()
+			return
+		}
+		if err != nil {
+			log.Printf("Error checking role: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions"})
+			c.Abort()
+			return
+		}
+
+		if !slices.Contains(roles, role) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient role"})
+			c.Abort()
+			return
+		}
+
+		c.Set("role", role)
 		c.Next()
 	}
 }
@@ -82,16 +194,10 @@ func getLimiter(userID string) *rate.Limiter {
 }
 
 func ExtractUserIDFromToken(tokenString string) (string, error) {
-	jwtSecretKey := []byte(appConfig.AppConfig.EncryptionKey)
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		return jwtSecretKey, nil
-	})
-
-	if err != nil || !token.Valid {
+	claims, err := auth.ParseAccessToken(tokenString)
+	if err != nil {
 		return "", err
 	}
 
-	claims := token.Claims.(jwt.MapClaims)
-	userID := claims["userID"]
-	return userID.(string), nil
-}
\ No newline at end of file
+	return claims.UserID, nil
+}
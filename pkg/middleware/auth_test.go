@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/souvik150/file-sharing-app/internal/auth"
+)
+
+func TestRequireScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name   string
+		scopes any
+		scope  string
+		want   int
+	}{
+		{name: "granted", scopes: []string{auth.ScopeFilesRead, auth.ScopeFilesWrite}, scope: auth.ScopeFilesWrite, want: http.StatusOK},
+		{name: "session carries all scopes", scopes: auth.AllScopes, scope: auth.ScopeShareCreate, want: http.StatusOK},
+		{name: "not granted", scopes: []string{auth.ScopeFilesRead}, scope: auth.ScopeFilesWrite, want: http.StatusForbidden},
+		{name: "no scopes", scopes: []string{}, scope: auth.ScopeFilesRead, want: http.StatusForbidden},
+		{name: "scopes unset", scopes: nil, scope: auth.ScopeFilesRead, want: http.StatusForbidden},
+		{name: "scopes of the wrong type", scopes: auth.ScopeFilesRead, scope: auth.ScopeFilesRead, want: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/", func(c *gin.Context) {
+				if tt.scopes != nil {
+					c.Set("scopes", tt.scopes)
+				}
+				c.Next()
+			}, RequireScope(tt.scope), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+			if recorder.Code != tt.want {
+				t.Errorf("got status %d, want %d", recorder.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequireRecentMFAWithoutSessionClaims(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name   string
+		claims any
+	}{
+		{name: "claims unset", claims: nil},
+		{name: "claims of the wrong type", claims: "not claims"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.POST("/", func(c *gin.Context) {
+				if tt.claims != nil {
+					c.Set("tokenClaims", tt.claims)
+				}
+				c.Next()
+			}, RequireRecentMFA(), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/", nil))
+			if recorder.Code != http.StatusUnauthorized {
+				t.Errorf("got status %d, want %d", recorder.Code, http.StatusUnauthorized)
+			}
+		})
+	}
+}
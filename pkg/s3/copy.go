@@ -0,0 +1,46 @@
+package s3
+
+import (
+	"context"
+	"log"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	appConfig "github.com/souvik150/file-sharing-app/internal/config"
+)
+
+// CopyObject duplicates an object within the bucket. The data stays
+// encrypted with the same key, so the copy decrypts like the original.
+func CopyObject(sourceKey, destinationKey string) error {
+	accessKey := appConfig.AppConfig.AWSAccessKey
+	secretKey := appConfig.AppConfig.AWSSecretKey
+	region := appConfig.AppConfig.AWSRegion
+	bucket := appConfig.AppConfig.BucketName
+
+	cfg, err := config.LoadDefaultConfig(context.TODO(),
+		config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(accessKey, secretKey, "")),
+		config.WithRegion(region),
+	)
+	if err != nil {
+		log.Printf("Failed to load AWS config: %v", err)
+		return err
+	}
+
+	s3Client := s3.NewFromConfig(cfg)
+
+	_, err = s3Client.CopyObject(context.TODO(), &s3.CopyObjectInput{
+		Bucket:     aws.String(bucket),
+		CopySource: aws.String(bucket + "/" + sourceKey),
+		Key:        aws.String(destinationKey),
+	})
+	if err != nil {
+		log.Printf("Error copying object %s to %s: %v", sourceKey, destinationKey, err)
+		return err
+	}
+
+	log.Printf("Object %s copied to %s", sourceKey, destinationKey)
+	return nil
+}
@@ -6,13 +6,21 @@ import (
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 
 	appConfig "github.com/souvik150/file-sharing-app/internal/config"
 )
 
 func DeleteFileFromS3(fileKey string) error {
-	cfg, err := config.LoadDefaultConfig(context.TODO())
+	accessKey := appConfig.AppConfig.AWSAccessKey
+	secretKey := appConfig.AppConfig.AWSSecretKey
+	region := appConfig.AppConfig.AWSRegion
+
+	cfg, err := config.LoadDefaultConfig(context.TODO(),
+		config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(accessKey, secretKey, "")),
+		config.WithRegion(region),
+	)
 	if err != nil {
 		log.Printf("Failed to load AWS config: %v", err)
 		return err
@@ -3,19 +3,47 @@ package s3
 import (
 	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"io"
 	"log"
+	"strconv"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 
 	appConfig "github.com/souvik150/file-sharing-app/internal/config"
 	"github.com/souvik150/file-sharing-app/pkg/utils"
 )
 
+// ErrObjectNotFound and ErrObjectUnreadable report stored content that is
+// lost or damaged, as opposed to S3 being unreachable.
+var ErrObjectNotFound = errors.New("object not found")
+var ErrObjectUnreadable = errors.New("object failed to decrypt")
+
 func DownloadFile(fileID string) ([]byte, error) {
+	content, err := OpenFile(fileID)
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+
+	data, err := io.ReadAll(content)
+	if err != nil {
+		log.Printf("Failed to read file %s: %v", fileID, err)
+		return nil, err
+	}
+	return data, nil
+}
+
+// OpenFile streams an object's decrypted content, holding at most one
+// encrypted part in memory. Content that fails to decrypt surfaces as
+// ErrObjectUnreadable, from Read for objects stored in parts. The caller must
+// close the reader.
+func OpenFile(fileID string) (io.ReadCloser, error) {
 	accessKey := appConfig.AppConfig.AWSAccessKey
 	secretKey := appConfig.AppConfig.AWSSecretKey
 	region := appConfig.AppConfig.AWSRegion
@@ -39,10 +67,36 @@ func DownloadFile(fileID string) ([]byte, error) {
 	})
 	if err != nil {
 		log.Printf("Failed to download file: %v", err)
+		var noSuchKey *types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return nil, fmt.Errorf("%w: %s", ErrObjectNotFound, fileID)
+		}
 		return nil, err
 	}
-	defer resp.Body.Close()
 
+	// Multipart uploads encrypt each part on its own.
+	if value, ok := resp.Metadata[chunkSizeMetadata]; ok {
+		chunkSize, convErr := strconv.Atoi(value)
+		if convErr != nil {
+			resp.Body.Close()
+			log.Printf("Invalid encryption chunk size %q on %s", value, fileID)
+			return nil, fmt.Errorf("%w: invalid chunk size %q", ErrObjectUnreadable, value)
+		}
+
+		newDecrypter := utils.NewLegacyChunkDecrypter
+		if resp.Metadata[chunkFormatMetadata] == boundChunks {
+			newDecrypter = utils.NewChunkDecrypter
+		}
+		body := &readErrorTracker{r: resp.Body}
+		decrypter, err := newDecrypter(body, encryptionKey, chunkSize)
+		if err != nil {
+			resp.Body.Close()
+			return nil, fmt.Errorf("%w: %v", ErrObjectUnreadable, err)
+		}
+		return &objectReader{decrypter: decrypter, body: body, closer: resp.Body}, nil
+	}
+
+	defer resp.Body.Close()
 	encryptedData := new(bytes.Buffer)
 	_, err = io.Copy(encryptedData, resp.Body)
 	if err != nil {
@@ -53,8 +107,45 @@ func DownloadFile(fileID string) ([]byte, error) {
 	decryptedData, err := utils.Decrypt(encryptedData.Bytes(), encryptionKey)
 	if err != nil {
 		log.Printf("Failed to decrypt file: %v", err)
-		return nil, err
+		return nil, fmt.Errorf("%w: %v", ErrObjectUnreadable, err)
+	}
+
+	return io.NopCloser(bytes.NewReader(decryptedData)), nil
+}
+
+// objectReader decrypts an object stored in parts as it is read. A body that
+// cannot be read is an S3 failure, while one that reads but does not decrypt
+// is damaged.
+type objectReader struct {
+	decrypter io.Reader
+	body      *readErrorTracker
+	closer    io.Closer
+}
+
+func (r *objectReader) Read(p []byte) (int, error) {
+	n, err := r.decrypter.Read(p)
+	if err != nil && err != io.EOF && r.body.err == nil {
+		log.Printf("Failed to decrypt file: %v", err)
+		err = fmt.Errorf("%w: %v", ErrObjectUnreadable, err)
 	}
+	return n, err
+}
+
+func (r *objectReader) Close() error {
+	return r.closer.Close()
+}
 
-	return decryptedData, nil
+// readErrorTracker remembers the error of the reader it wraps, other than
+// io.EOF.
+type readErrorTracker struct {
+	r   io.Reader
+	err error
+}
+
+func (t *readErrorTracker) Read(p []byte) (int, error) {
+	n, err := t.r.Read(p)
+	if err != nil && err != io.EOF {
+		t.err = err
+	}
+	return n, err
 }
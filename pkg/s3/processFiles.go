@@ -1,60 +1,131 @@
 package s3
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
 	"sync"
 
 	appConfig "github.com/souvik150/file-sharing-app/internal/config"
-	"github.com/souvik150/file-sharing-app/internal/socket"
 )
 
-func ProcessFilesAsync(fileNames []string, ownerID string) {
-	var wg sync.WaitGroup
-	wg.Add(len(fileNames))
-
-	for _, fileName := range fileNames {
-		go func(fileName string) {
-			defer wg.Done()
-			filePath := filepath.Join(fileName)
+// UploadedFile describes a local file handled by ProcessFilesAsync. SHA256 is
+// the hex digest of its plaintext. Deduplicated files were not uploaded
+// because identical content is already stored.
+type UploadedFile struct {
+	Key          string
+	SHA256       string
+	Size         int64
+	Deduplicated bool
+}
 
-			file, err := os.Open(filePath)
-			if err != nil {
-				log.Printf("❌ Error opening file %s: %v", fileName, err)
-				return
-			}
-			defer file.Close()
+// FailedUpload is a local file ProcessFilesAsync could not store.
+type FailedUpload struct {
+	Key string
+	Err error
+}
 
-			fileInfo, err := file.Stat()
-			if err != nil {
-				log.Printf("❌ Error getting file info for %s: %v", fileName, err)
-				return
-			}
-			
-			fileName = fileName[7:]
-			bucket := appConfig.AppConfig.BucketName
-			err = UploadFileConcurrently(bucket, fileName, file, fileInfo.Size())
-			if err != nil {
-				log.Printf("❌ Error uploading file %s to S3: %v", fileName, err)
-				return
-			}
+// ProcessFilesAsync hashes the local files, uploads them to S3 and removes
+// them, returning the files that were processed and those that failed. Each
+// file is stored under its base name. When skip is set it is called with each
+// key and digest before uploading, and a true result skips the upload of
+// content that is already stored.
+func ProcessFilesAsync(fileNames []string, skip func(key, sha256 string) bool) ([]UploadedFile, []FailedUpload) {
+	var uploaded []UploadedFile
+	var failed []FailedUpload
+	var mu sync.Mutex
 
-			log.Printf("✅ File %s successfully uploaded to S3", fileName)
-		}(fileName)
+	// UPLOAD_WORKERS workers send the files to S3, one file at a time each.
+	workers := appConfig.AppConfig.UploadWorkers
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(fileNames) {
+		workers = len(fileNames)
 	}
 
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for fileName := range jobs {
+				result, err := processFile(fileName, skip)
+				mu.Lock()
+				if err != nil {
+					failed = append(failed, FailedUpload{Key: result.Key, Err: err})
+				} else {
+					uploaded = append(uploaded, result)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, fileName := range fileNames {
+		jobs <- fileName
+	}
+	close(jobs)
 	wg.Wait()
 
+	// Failed files are removed too; their owners are told to upload again.
 	for _, fileName := range fileNames {
 		err := os.Remove(fileName)
-			if err != nil {
-				log.Printf("⚠️ Error deleting local file %s after upload: %v", fileName, err)
-			} else {
-				log.Printf("🗑️ Local file %s deleted after successful upload", fileName)
-			}
-		}	
-	
-	socket.NotifyUser(ownerID, "All files processed and uploaded to S3.")
-	log.Println("All files processed and uploaded to S3.")
-}
\ No newline at end of file
+		if err != nil {
+			log.Printf("⚠️ Error deleting local file %s after processing: %v", fileName, err)
+		} else {
+			log.Printf("🗑️ Local file %s deleted after processing", fileName)
+		}
+	}
+
+	log.Printf("Processed %d files, %d failed", len(fileNames), len(failed))
+	return uploaded, failed
+}
+
+// processFile hashes and uploads one local file. The result carries the
+// file's key even when it fails.
+func processFile(fileName string, skip func(key, sha256 string) bool) (UploadedFile, error) {
+	result := UploadedFile{Key: filepath.Base(fileName)}
+
+	file, err := os.Open(fileName)
+	if err != nil {
+		log.Printf("❌ Error opening file %s: %v", fileName, err)
+		return result, err
+	}
+	defer file.Close()
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		log.Printf("❌ Error getting file info for %s: %v", fileName, err)
+		return result, err
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		log.Printf("❌ Error hashing file %s: %v", fileName, err)
+		return result, err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		log.Printf("❌ Error rewinding file %s: %v", fileName, err)
+		return result, err
+	}
+	result.SHA256 = hex.EncodeToString(hash.Sum(nil))
+	result.Size = fileInfo.Size()
+
+	if skip != nil && skip(result.Key, result.SHA256) {
+		result.Deduplicated = true
+		log.Printf("♻️ File %s matches stored content, upload skipped", result.Key)
+		return result, nil
+	}
+
+	bucket := appConfig.AppConfig.BucketName
+	if err := UploadFileConcurrently(bucket, result.Key, file, result.Size); err != nil {
+		log.Printf("❌ Error uploading file %s to S3: %v", result.Key, err)
+		return result, err
+	}
+	log.Printf("✅ File %s successfully uploaded to S3", result.Key)
+	return result, nil
+}
@@ -0,0 +1,43 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	appConfig "github.com/souvik150/file-sharing-app/internal/config"
+	"github.com/souvik150/file-sharing-app/pkg/utils"
+)
+
+// PutObject encrypts and stores a small object generated by the server, such
+// as a thumbnail, in a single request.
+func PutObject(key string, data []byte) error {
+	cfg, err := config.LoadDefaultConfig(context.TODO(),
+		config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(appConfig.AppConfig.AWSAccessKey, appConfig.AppConfig.AWSSecretKey, "")),
+		config.WithRegion(appConfig.AppConfig.AWSRegion),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to load AWS config: %v", err)
+	}
+
+	encryptedData, err := utils.Encrypt(data, []byte(appConfig.AppConfig.EncryptionKey))
+	if err != nil {
+		return fmt.Errorf("failed to encrypt object: %v", err)
+	}
+
+	_, err = s3.NewFromConfig(cfg).PutObject(context.TODO(), &s3.PutObjectInput{
+		Bucket:     aws.String(appConfig.AppConfig.BucketName),
+		Key:        aws.String(key),
+		Body:       bytes.NewReader(encryptedData),
+		ContentMD5: contentMD5(encryptedData),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload object %s: %v", key, err)
+	}
+	return nil
+}
@@ -3,11 +3,14 @@ package s3
 import (
 	"bytes"
 	"context"
+	"crypto/md5"
+	"encoding/base64"
 	"fmt"
 	"io"
 	"log"
 	"mime/multipart"
 	"sort"
+	"strconv"
 	"sync"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -21,9 +24,26 @@ import (
 )
 
 const (
-	partSize         = 5 * 1024 * 1024
+	partSize = 5 * 1024 * 1024
+	// partWorkers is how many parts of one file are sent to S3 at once.
+	partWorkers = 4
+	// chunkSizeMetadata marks objects whose parts were encrypted one by one
+	// and records how much plaintext each part holds.
+	chunkSizeMetadata = "encryption-chunk-size"
+	// chunkFormatMetadata is set to boundChunks on objects whose parts are
+	// bound to their position with utils.EncryptChunk. Older chunked objects
+	// lack it.
+	chunkFormatMetadata = "encryption-chunk-format"
+	boundChunks         = "bound"
 )
 
+// contentMD5 is the Content-MD5 header for a request body, which S3 checks
+// before storing it.
+func contentMD5(data []byte) *string {
+	sum := md5.Sum(data)
+	return aws.String(base64.StdEncoding.EncodeToString(sum[:]))
+}
+
 func UploadFileConcurrently(bucket, key string, file multipart.File, fileSize int64) error {
 	accessKey := appConfig.AppConfig.AWSAccessKey
 	secretKey := appConfig.AppConfig.AWSSecretKey
@@ -58,9 +78,10 @@ func UploadFileConcurrently(bucket, key string, file multipart.File, fileSize in
 		}
 
 		_, err = s3Svc.PutObject(context.TODO(), &s3.PutObjectInput{
-			Bucket: aws.String(bucket),
-			Key:    aws.String(key),
-			Body:   bytes.NewReader(encryptedData),
+			Bucket:     aws.String(bucket),
+			Key:        aws.String(key),
+			Body:       bytes.NewReader(encryptedData),
+			ContentMD5: contentMD5(encryptedData),
 		})
 		if err != nil {
 			log.Printf("Failed to upload file as single part: %v", err)
@@ -74,6 +95,10 @@ func UploadFileConcurrently(bucket, key string, file multipart.File, fileSize in
 	createResp, err := s3Svc.CreateMultipartUpload(context.TODO(), &s3.CreateMultipartUploadInput{
 		Bucket: aws.String(bucket),
 		Key:    aws.String(key),
+		Metadata: map[string]string{
+			chunkSizeMetadata:   strconv.Itoa(partSize),
+			chunkFormatMetadata: boundChunks,
+		},
 	})
 	if err != nil {
 		log.Printf("Failed to initiate multipart upload: %v", err)
@@ -83,54 +108,91 @@ func UploadFileConcurrently(bucket, key string, file multipart.File, fileSize in
 	uploadID := createResp.UploadId
 	var completedParts []types.CompletedPart
 	var mu sync.Mutex
-	
+
 	errCh := make(chan error, 1)
+	reportErr := func(err error) {
+		select {
+		case errCh <- err:
+		default:
+		}
+	}
+
+	// A fixed number of workers encrypt and send the parts, so at most one
+	// part per worker and the one being read are held in memory.
+	type filePart struct {
+		number int
+		data   []byte
+	}
+	parts := make(chan filePart)
+	totalParts := int((fileSize + partSize - 1) / partSize)
 
 	var wg sync.WaitGroup
-	partNum := 1
+	for w := 0; w < partWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for part := range parts {
+				encryptedPart, err := utils.EncryptChunk(part.data, encryptionKey, uint64(part.number-1), part.number == totalParts)
+				if err != nil {
+					log.Printf("Failed to encrypt part %d: %v", part.number, err)
+					reportErr(fmt.Errorf("failed to encrypt part %d: %v", part.number, err))
+					continue
+				}
 
+				uploadResp, err := s3Svc.UploadPart(context.TODO(), &s3.UploadPartInput{
+					Bucket:     aws.String(bucket),
+					Key:        aws.String(key),
+					PartNumber: aws.Int32(int32(part.number)),
+					UploadId:   uploadID,
+					Body:       bytes.NewReader(encryptedPart),
+					ContentMD5: contentMD5(encryptedPart),
+				})
+				if err != nil {
+					log.Printf("Failed to upload part %d: %v", part.number, err)
+					reportErr(fmt.Errorf("failed to upload part %d: %v", part.number, err))
+					continue
+				}
+
+				mu.Lock()
+				completedParts = append(completedParts, types.CompletedPart{
+					ETag:       uploadResp.ETag,
+					PartNumber: aws.Int32(int32(part.number)),
+				})
+				mu.Unlock()
+			}
+		}()
+	}
+
+	partNum := 1
 	for {
+		// Parts are encrypted separately, so every part but the last must hold
+		// exactly partSize bytes for downloads to find the part boundaries.
 		buffer := make([]byte, partSize)
-		bytesRead, err := file.Read(buffer)
+		bytesRead, err := io.ReadFull(file, buffer)
 		if err == io.EOF {
 			break
 		}
-		if err != nil {
+		if err != nil && err != io.ErrUnexpectedEOF {
 			log.Printf("Error reading file: %v", err)
-			return fmt.Errorf("error reading file: %v", err)
+			reportErr(fmt.Errorf("error reading file: %v", err))
+			break
 		}
 
-		wg.Add(1)
-		go func(partNum int, buffer []byte) {
-			defer wg.Done()
-			uploadResp, err := s3Svc.UploadPart(context.TODO(), &s3.UploadPartInput{
-				Bucket:     aws.String(bucket),
-				Key:        aws.String(key),
-				PartNumber: aws.Int32(int32(partNum)),
-				UploadId:   uploadID,
-				Body:       bytes.NewReader(buffer[:bytesRead]),
-			})
-			if err != nil {
-				log.Printf("Failed to upload part %d: %v", partNum, err)
-				select {
-				case errCh <- fmt.Errorf("failed to upload part %d: %v", partNum, err):
-				default:
-				}
-				return
-			}
-
-			mu.Lock()
-			completedParts = append(completedParts, types.CompletedPart{
-				ETag:       uploadResp.ETag,
-				PartNumber: aws.Int32(int32(partNum)),
-			})
-			mu.Unlock()
-		}(partNum, buffer[:bytesRead])
+		parts <- filePart{number: partNum, data: buffer[:bytesRead]}
 		partNum++
 	}
-
+	close(parts)
 	wg.Wait()
 
+	// Only the part expected to be last was marked final, so the file must
+	// have held exactly fileSize bytes.
+	if parts := partNum - 1; parts != totalParts {
+		select {
+		case errCh <- fmt.Errorf("read %d parts of %s, expected %d", parts, key, totalParts):
+		default:
+		}
+	}
+
 	select {
 	case err := <-errCh:
 		log.Printf("Aborting multipart upload due to error: %v", err)
@@ -150,10 +212,7 @@ func UploadFileConcurrently(bucket, key string, file multipart.File, fileSize in
 		return *completedParts[i].PartNumber < *completedParts[j].PartNumber
 	})
 
-	log.Println("Completed Parts:")
-	for _, part := range completedParts {
-		log.Printf("Part Number: %d, ETag: %s", *part.PartNumber, *part.ETag)
-	}
+	log.Printf("Uploaded %d parts of %s, each verified by S3 against its Content-MD5", len(completedParts), key)
 
 	_, err = s3Svc.CompleteMultipartUpload(context.TODO(), &s3.CompleteMultipartUploadInput{
 		Bucket: aws.String(bucket),
@@ -0,0 +1,66 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA1 seed "12345678901234567890" from RFC 6238 appendix B,
+// base32 encoded.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestValidateRFC6238Vectors(t *testing.T) {
+	// The RFC lists 8 digit codes; the last 6 digits are the 6 digit code.
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{unix: 59, code: "287082"},
+		{unix: 1111111109, code: "081804"},
+		{unix: 1111111111, code: "050471"},
+		{unix: 1234567890, code: "005924"},
+		{unix: 2000000000, code: "279037"},
+		{unix: 20000000000, code: "353130"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			at := time.Unix(tt.unix, 0)
+			step, ok := Validate(rfcSecret, tt.code, at)
+			if !ok {
+				t.Fatalf("Validate(%q) at %d rejected a valid code", tt.code, tt.unix)
+			}
+			if step != Step(at) {
+				t.Errorf("matched step %d, want %d", step, Step(at))
+			}
+		})
+	}
+}
+
+func TestValidateSkew(t *testing.T) {
+	at := time.Unix(1234567890, 0)
+
+	tests := []struct {
+		name   string
+		secret string
+		code   string
+		offset time.Duration
+		want   bool
+	}{
+		{name: "previous step", secret: rfcSecret, code: "005924", offset: period * time.Second, want: true},
+		{name: "next step", secret: rfcSecret, code: "005924", offset: -period * time.Second, want: true},
+		{name: "two steps late", secret: rfcSecret, code: "005924", offset: 2 * period * time.Second, want: false},
+		{name: "wrong code", secret: rfcSecret, code: "005925", want: false},
+		{name: "short code", secret: rfcSecret, code: "05924", want: false},
+		{name: "lowercase secret", secret: "gezdgnbvgy3tqojqgezdgnbvgy3tqojq", code: "005924", want: true},
+		{name: "invalid secret", secret: "not base32!", code: "005924", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := Validate(tt.secret, tt.code, at.Add(tt.offset)); ok != tt.want {
+				t.Errorf("Validate = %v, want %v", ok, tt.want)
+			}
+		})
+	}
+}
@@ -1,9 +1,11 @@
 package utils
 
 import (
+	"bufio"
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
+	"encoding/binary"
 	"fmt"
 	"io"
 )
@@ -52,3 +54,152 @@ func Decrypt(encryptedData []byte, key []byte) ([]byte, error) {
 
 	return decryptedData, nil
 }
+
+// ChunkOverhead is what encryption adds to each chunk: the GCM nonce and
+// tag.
+const ChunkOverhead = 12 + 16
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %v", err)
+	}
+
+	aesGCM, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM cipher: %v", err)
+	}
+	return aesGCM, nil
+}
+
+// chunkAAD binds a chunk to its position in the object and marks the final
+// one, so chunks that were reordered, duplicated or cut off fail to
+// authenticate.
+func chunkAAD(index uint64, final bool) []byte {
+	aad := make([]byte, 9)
+	binary.BigEndian.PutUint64(aad, index)
+	if final {
+		aad[8] = 1
+	}
+	return aad
+}
+
+// EncryptChunk encrypts one chunk of an object written as a sequence of
+// chunks. index counts chunks from 0 and final marks the last one.
+func EncryptChunk(data []byte, key []byte, index uint64, final bool) ([]byte, error) {
+	aesGCM, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aesGCM.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %v", err)
+	}
+
+	return aesGCM.Seal(nonce, nonce, data, chunkAAD(index, final)), nil
+}
+
+// NewChunkDecrypter returns a reader of the plaintext of chunks written by
+// EncryptChunk, each holding chunkSize bytes of plaintext except for a
+// shorter final one. Reading fails if the chunks were reordered, dropped or
+// duplicated, or the final chunk is missing.
+func NewChunkDecrypter(r io.Reader, key []byte, chunkSize int) (io.Reader, error) {
+	return newChunkDecrypter(r, key, chunkSize, true)
+}
+
+// NewLegacyChunkDecrypter reads objects whose chunks were encrypted with
+// Encrypt, before chunks were bound to their position. It cannot tell when
+// chunks were rearranged.
+func NewLegacyChunkDecrypter(r io.Reader, key []byte, chunkSize int) (io.Reader, error) {
+	return newChunkDecrypter(r, key, chunkSize, false)
+}
+
+func newChunkDecrypter(r io.Reader, key []byte, chunkSize int, bound bool) (io.Reader, error) {
+	if chunkSize <= 0 {
+		return nil, fmt.Errorf("invalid chunk size %d", chunkSize)
+	}
+	aesGCM, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &chunkDecrypter{
+		r:      bufio.NewReader(r),
+		aesGCM: aesGCM,
+		chunk:  make([]byte, chunkSize+ChunkOverhead),
+		bound:  bound,
+	}, nil
+}
+
+type chunkDecrypter struct {
+	r      *bufio.Reader
+	aesGCM cipher.AEAD
+	chunk  []byte
+	bound  bool
+	index  uint64
+	// plain is decrypted data not yet read.
+	plain []byte
+	done  bool
+	err   error
+}
+
+func (d *chunkDecrypter) Read(p []byte) (int, error) {
+	for len(d.plain) == 0 {
+		if d.err != nil {
+			return 0, d.err
+		}
+		if d.done {
+			return 0, io.EOF
+		}
+		d.err = d.next()
+	}
+
+	n := copy(p, d.plain)
+	d.plain = d.plain[n:]
+	return n, nil
+}
+
+// next decrypts the following chunk. A chunk is final when nothing follows
+// it, which a bound chunk must have been encrypted to expect.
+func (d *chunkDecrypter) next() error {
+	n, err := io.ReadFull(d.r, d.chunk)
+	if err == io.EOF {
+		if d.bound {
+			return fmt.Errorf("encrypted data ends before its final chunk")
+		}
+		d.done = true
+		return nil
+	}
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return err
+	}
+
+	final := err == io.ErrUnexpectedEOF
+	if !final {
+		if _, peekErr := d.r.Peek(1); peekErr == io.EOF {
+			final = true
+		} else if peekErr != nil {
+			return peekErr
+		}
+	}
+
+	nonceSize := d.aesGCM.NonceSize()
+	if n < nonceSize {
+		return fmt.Errorf("ciphertext too short")
+	}
+
+	var aad []byte
+	if d.bound {
+		aad = chunkAAD(d.index, final)
+	}
+	plain, err := d.aesGCM.Open(d.chunk[nonceSize:nonceSize], d.chunk[:nonceSize], d.chunk[nonceSize:n], aad)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt chunk %d: %v", d.index, err)
+	}
+
+	d.plain = plain
+	d.index++
+	d.done = final
+	return nil
+}
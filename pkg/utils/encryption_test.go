@@ -0,0 +1,153 @@
+package utils
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+var testKey = []byte("0123456789abcdef0123456789abcdef")
+
+const testChunkSize = 16
+
+// encryptChunks splits data into chunks of testChunkSize the way multipart
+// uploads do and encrypts each of them.
+func encryptChunks(t *testing.T, data []byte) [][]byte {
+	t.Helper()
+
+	var chunks [][]byte
+	for index := 0; ; index++ {
+		n := min(testChunkSize, len(data))
+		final := n == len(data)
+		chunk, err := EncryptChunk(data[:n], testKey, uint64(index), final)
+		if err != nil {
+			t.Fatalf("EncryptChunk: %v", err)
+		}
+		chunks = append(chunks, chunk)
+		data = data[n:]
+		if final {
+			return chunks
+		}
+	}
+}
+
+func decryptAll(encrypted []byte) ([]byte, error) {
+	decrypter, err := NewChunkDecrypter(bytes.NewReader(encrypted), testKey, testChunkSize)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(decrypter)
+}
+
+func TestChunkRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		size int
+	}{
+		{name: "empty", size: 0},
+		{name: "shorter than a chunk", size: 5},
+		{name: "one full chunk", size: testChunkSize},
+		{name: "exact multiple of chunks", size: 3 * testChunkSize},
+		{name: "short final chunk", size: 2*testChunkSize + 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := bytes.Repeat([]byte("abcdefghij"), 10)[:tt.size]
+			got, err := decryptAll(bytes.Join(encryptChunks(t, data), nil))
+			if err != nil {
+				t.Fatalf("decrypt: %v", err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Errorf("got %q, want %q", got, data)
+			}
+		})
+	}
+}
+
+func TestChunkDecrypterRejectsTampering(t *testing.T) {
+	data := bytes.Repeat([]byte("x"), 3*testChunkSize+4)
+
+	tests := []struct {
+		name   string
+		modify func(chunks [][]byte) [][]byte
+	}{
+		{name: "flipped bit", modify: func(chunks [][]byte) [][]byte {
+			chunks[1][ChunkOverhead] ^= 1
+			return chunks
+		}},
+		{name: "reordered", modify: func(chunks [][]byte) [][]byte {
+			chunks[0], chunks[1] = chunks[1], chunks[0]
+			return chunks
+		}},
+		{name: "duplicated", modify: func(chunks [][]byte) [][]byte {
+			return append(chunks[:2], chunks[1:]...)
+		}},
+		{name: "middle chunk dropped", modify: func(chunks [][]byte) [][]byte {
+			return append(chunks[:1], chunks[2:]...)
+		}},
+		{name: "final chunk dropped", modify: func(chunks [][]byte) [][]byte {
+			return chunks[:len(chunks)-1]
+		}},
+		{name: "final chunk cut short", modify: func(chunks [][]byte) [][]byte {
+			last := chunks[len(chunks)-1]
+			chunks[len(chunks)-1] = last[:len(last)-1]
+			return chunks
+		}},
+		{name: "trailing data", modify: func(chunks [][]byte) [][]byte {
+			return append(chunks, []byte("extra"))
+		}},
+		{name: "nothing left", modify: func(chunks [][]byte) [][]byte {
+			return nil
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encrypted := bytes.Join(tt.modify(encryptChunks(t, data)), nil)
+			if _, err := decryptAll(encrypted); err == nil {
+				t.Error("decrypted tampered data without an error")
+			}
+		})
+	}
+}
+
+func TestLegacyChunkDecrypter(t *testing.T) {
+	data := bytes.Repeat([]byte("legacy"), 10)
+
+	var encrypted []byte
+	for rest := data; len(rest) > 0; {
+		n := min(testChunkSize, len(rest))
+		chunk, err := Encrypt(rest[:n], testKey)
+		if err != nil {
+			t.Fatalf("Encrypt: %v", err)
+		}
+		encrypted = append(encrypted, chunk...)
+		rest = rest[n:]
+	}
+
+	decrypter, err := NewLegacyChunkDecrypter(bytes.NewReader(encrypted), testKey, testChunkSize)
+	if err != nil {
+		t.Fatalf("NewLegacyChunkDecrypter: %v", err)
+	}
+	got, err := io.ReadAll(decrypter)
+	if err != nil {
+		t.Fatalf("decrypt: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("got %q, want %q", got, data)
+	}
+
+	// Legacy chunks carry no position, so they do not pass as bound ones.
+	if _, err := decryptAll(encrypted); err == nil {
+		t.Error("legacy chunks decrypted as bound chunks")
+	}
+}
+
+func TestNewChunkDecrypterRejectsInvalidChunkSize(t *testing.T) {
+	for _, size := range []int{0, -1} {
+		if _, err := NewChunkDecrypter(bytes.NewReader(nil), testKey, size); err == nil {
+			t.Errorf("chunk size %d accepted", size)
+		}
+	}
+}